@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"example/discord-bookmarker/internal/export"
+)
+
+// runStats runs the stats command.
+func runStats(env commandEnv, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: stats")
+	}
+	st, closeDB, err := env.openStorageReadOnly()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	s, err := st.Stats()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "Users:     %d\n", s.Users)
+	fmt.Fprintf(env.out, "Bookmarks: %d\n", s.Bookmarks)
+	fmt.Fprintf(env.out, "Reminders: %d\n", s.Reminders)
+	return nil
+}
+
+// runListUser runs the list-user command.
+func runListUser(env commandEnv, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: list-user USER_ID")
+	}
+	userID := args[0]
+	st, closeDB, err := env.openStorageReadOnly()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	bookmarks, err := st.ListBookmarksForUser(userID)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(env.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCREATED AT\tDUE AT\tTAGS\tCONTENT")
+	for _, bm := range bookmarks {
+		tags, err := st.ListTagsForBookmark(bm.ID)
+		if err != nil {
+			return err
+		}
+		var dueAt string
+		if bm.DueAt.Valid {
+			dueAt = bm.DueAt.Time.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(
+			tw,
+			"%d\t%s\t%s\t%s\t%s\n",
+			bm.ID,
+			bm.CreatedAt.Local().Format(time.DateTime),
+			dueAt,
+			strings.Join(tags, ", "),
+			truncate(strings.Join(strings.Fields(bm.Content), " "), 50),
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "\n%d bookmarks\n", len(bookmarks))
+	return nil
+}
+
+// runDeleteUser runs the delete-user command.
+func runDeleteUser(env commandEnv, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: delete-user USER_ID")
+	}
+	userID := args[0]
+	st, closeDB, err := env.openStorage()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	n, err := st.DeleteUser(userID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "Deleted all data of user %s including %d bookmarks\n", userID, n)
+	return nil
+}
+
+// runExportUser runs the export-user command.
+// The bookmarks are written to stdout unless a file is given.
+// Author names are not included, because they can only be fetched from Discord.
+func runExportUser(env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("export-user", flag.ContinueOnError)
+	formatFlag := fs.String("format", string(export.FormatJSON), "file format: json, csv, markdown or html")
+	outFlag := fs.String("out", "", "path of the file to create. Writes to stdout if not set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: export-user [-format FORMAT] [-out FILE] USER_ID")
+	}
+	userID := fs.Arg(0)
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+	st, closeDB, err := env.openStorageReadOnly()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	bookmarks, err := st.ListBookmarksForUser(userID)
+	if err != nil {
+		return err
+	}
+	xx := make([]export.Bookmark, 0, len(bookmarks))
+	for _, bm := range bookmarks {
+		tags, err := st.ListTagsForBookmark(bm.ID)
+		if err != nil {
+			return err
+		}
+		xx = append(xx, export.NewBookmark(bm, tags))
+	}
+	if *outFlag == "" {
+		return export.Write(env.out, format, xx)
+	}
+	return writeExportFile(*outFlag, format, xx)
+}
+
+func writeExportFile(path string, format export.Format, bookmarks []export.Bookmark) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(f, format, bookmarks); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runVacuum runs the vacuum command.
+func runVacuum(env commandEnv, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: vacuum")
+	}
+	st, closeDB, err := env.openStorage()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	if err := st.Vacuum(); err != nil {
+		return err
+	}
+	fmt.Fprintln(env.out, "Database vacuumed")
+	return nil
+}
+
+// runIntegrityCheck runs the integrity-check command.
+// It fails when problems were found.
+func runIntegrityCheck(env commandEnv, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: integrity-check")
+	}
+	st, closeDB, err := env.openStorageReadOnly()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	problems, err := st.IntegrityCheck()
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(env.out, p)
+		}
+		return fmt.Errorf("integrity check found %d problems", len(problems))
+	}
+	fmt.Fprintln(env.out, "ok")
+	return nil
+}
+
+// truncate returns s shortened to at most n characters.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+// runBackup runs the backup command with its arguments.
+// It is safe to run while the bot is using the database.
+func runBackup(env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("out", "", "path of the backup file to create")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return fmt.Errorf("usage: backup -out <file>")
+	}
+	dbRW, dbRO, err := storage.OpenDB(env.dsn)
+	if err != nil {
+		return err
+	}
+	defer dbRW.Close()
+	defer dbRO.Close()
+	return storage.Backup(context.Background(), dbRO, *out)
+}
+
+// runRestore runs the restore command with its arguments.
+// The bot must be stopped before.
+func runRestore(env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := fs.String("in", "", "path of the backup file to restore")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("usage: restore -in <file>")
+	}
+	v, err := storage.Restore(*in, env.dbPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "Database restored from %s with schema version %d\n", *in, v)
+	return nil
+}
+
+// runScheduledBackups creates a daily backup in dir and keeps the newest backups only.
+// It checks every hour whether today's backup is missing and runs until ctx is canceled.
+func runScheduledBackups(ctx context.Context, db *sql.DB, dir string, keep int) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if _, err := storage.RotateBackups(ctx, db, dir, keep, time.Now()); err != nil {
+			slog.Error("Scheduled backup failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+// commandEnv is the environment commands are run in.
+type commandEnv struct {
+	dbPath string
+	dsn    string
+	out    io.Writer
+}
+
+// openStorage opens the existing database for a command which changes data.
+// The returned function closes the database.
+func (env commandEnv) openStorage() (*storage.Storage, func(), error) {
+	return env.open(false)
+}
+
+// openStorageReadOnly opens the existing database for a command which only reads data.
+// The returned function closes the database.
+func (env commandEnv) openStorageReadOnly() (*storage.Storage, func(), error) {
+	return env.open(true)
+}
+
+// open opens the existing database.
+// Unlike the bot, commands never create a database or upgrade its schema,
+// because they might be run against the wrong file or a database in use.
+func (env commandEnv) open(readOnly bool) (*storage.Storage, func(), error) {
+	if _, err := os.Stat(env.dbPath); err != nil {
+		return nil, nil, fmt.Errorf("database not found: %w", err)
+	}
+	dbRW, dbRO, err := storage.OpenDB(env.dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeDB := func() {
+		dbRW.Close()
+		dbRO.Close()
+	}
+	v, err := storage.SchemaVersion(dbRO)
+	if err != nil {
+		closeDB()
+		return nil, nil, err
+	}
+	if latest := storage.LatestSchemaVersion(); v != latest {
+		closeDB()
+		if v < latest {
+			return nil, nil, fmt.Errorf("database schema version %d is outdated. Upgrade it with -migrate-only first", v)
+		}
+		return nil, nil, fmt.Errorf("database schema version %d is newer than %d", v, latest)
+	}
+	if readOnly {
+		return storage.New(dbRO, dbRO), closeDB, nil
+	}
+	return storage.New(dbRW, dbRO), closeDB, nil
+}
+
+// command is a command of bookmarkersrv besides running the bot.
+// Commands work offline against the database.
+type command struct {
+	name        string
+	args        string
+	description string
+	run         func(env commandEnv, args []string) error
+}
+
+// commands are all commands ordered by name.
+var commands = []command{
+	{"backup", "-out FILE", "creates a backup of the database while it is in use", runBackup},
+	{"delete-user", "USER_ID", "deletes all data of a user", runDeleteUser},
+	{"export-user", "[-format FORMAT] [-out FILE] USER_ID", "exports the bookmarks of a user", runExportUser},
+	{"integrity-check", "", "checks the integrity of the database", runIntegrityCheck},
+	{"list-user", "USER_ID", "lists the bookmarks of a user", runListUser},
+	{"migrate", "status", "shows the applied and pending database migrations", runMigrate},
+	{"restore", "-in FILE", "replaces the database with a backup. The bot must be stopped.", runRestore},
+	{"stats", "", "shows statistics about users, bookmarks and reminders", runStats},
+	{"vacuum", "", "rebuilds the database file to reclaim unused space", runVacuum},
+}
+
+// findCommand returns the command with a name and reports whether it was found.
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// printCommands prints the usage of all commands.
+func printCommands(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", c.name, c.args, c.description)
+	}
+	tw.Flush()
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"cmp"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -13,11 +14,14 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
+	_ "time/tzdata" // ensures user timezones are available on all systems
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/joho/godotenv"
 
 	"example/discord-bookmarker/internal/bot"
+	"example/discord-bookmarker/internal/scheduler"
 	"example/discord-bookmarker/internal/storage"
 )
 
@@ -42,9 +46,62 @@ func main() {
 		resetDataFlag     = flag.Bool("reset-data", false, "resets all data")
 		logLevelFlag      = flag.String("log-level", cmp.Or(os.Getenv("LOG_LEVEL"), "info"), "Set log level for this session. Can be set by env.")
 		resetCommandsFlag = flag.Bool("reset-commands", false, "recreates Discord commands. Requires user re-install.")
+		catchUpFlag       = flag.String("catch-up", cmp.Or(os.Getenv("CATCH_UP"), "all"), "How to send reminders missed during downtime: all, digest or drop. Can be set by env.")
+		catchUpHoursFlag  = flag.Int("catch-up-hours", 24, "Missed reminders older than this many hours are dropped when catch-up is drop.")
+		migrateOnlyFlag   = flag.Bool("migrate-only", false, "upgrades the database schema and exits")
+		backupKeepFlag    = flag.Int("backup-keep", 0, "Creates a daily backup in the data dir and keeps this many. Disabled when 0.")
 	)
+	flag.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage: %s [flags] [command]\n\n", os.Args[0])
+		fmt.Fprintln(w, "Commands:")
+		printCommands(w)
+		fmt.Fprintln(w, "\nFlags:")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	var dataDir string
+	if *dataDirFlag != "" {
+		dataDir = *dataDirFlag
+	} else {
+		p, err := os.Getwd()
+		if err != nil {
+			slog.Error("Failed to get current directory", "error", err)
+			os.Exit(1)
+		}
+		dataDir = p
+	}
+	dbPath := filepath.Join(dataDir, dbFileName)
+	dsn := "file:///" + filepath.ToSlash(dbPath)
+
+	// Commands
+	if name := flag.Arg(0); name != "" {
+		c, ok := findCommand(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n", name)
+			flag.Usage()
+			os.Exit(2)
+		}
+		env := commandEnv{dbPath: dbPath, dsn: dsn, out: os.Stdout}
+		if err := c.run(env, flag.Args()[1:]); err != nil {
+			slog.Error("Command failed", "command", name, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *migrateOnlyFlag {
+		dbRW, dbRO, err := storage.InitDB(dsn)
+		if err != nil {
+			slog.Error("Failed to migrate database", "dsn", dsn, "error", err)
+			os.Exit(1)
+		}
+		dbRW.Close()
+		dbRO.Close()
+		slog.Info("Database is up to date", "version", storage.LatestSchemaVersion())
+		return
+	}
+
 	// Validations
 	if *appIDFlag == "" {
 		slog.Error("app ID missing")
@@ -54,6 +111,11 @@ func main() {
 		slog.Error("bot token missing")
 		os.Exit(1)
 	}
+	catchUpMode, err := scheduler.ParseCatchUpMode(*catchUpFlag)
+	if err != nil {
+		slog.Error("Invalid catch-up mode", "error", err)
+		os.Exit(1)
+	}
 
 	// set manual log level for this session if requested
 	m := map[string]slog.Level{
@@ -71,19 +133,6 @@ func main() {
 	slog.SetLogLoggerLevel(l)
 	slog.SetLogLoggerLevel(slog.LevelInfo)
 
-	var dataDir string
-	if *dataDirFlag != "" {
-		dataDir = *dataDirFlag
-	} else {
-		p, err := os.Getwd()
-		if err != nil {
-			slog.Error("Failed to get current directory", "error", err)
-			os.Exit(1)
-		}
-		dataDir = p
-	}
-
-	dbPath := filepath.Join(dataDir, dbFileName)
 	if *resetDataFlag {
 		err := deleteDatabaseFiles(dbPath)
 		if err != nil {
@@ -91,7 +140,6 @@ func main() {
 			os.Exit(1)
 		}
 	}
-	dsn := "file:///" + filepath.ToSlash(dbPath)
 	dbRW, dbRO, err := storage.InitDB(dsn)
 	if err != nil {
 		slog.Error("Failed to initialize database", "dsn", dsn, "error", err)
@@ -110,7 +158,10 @@ func main() {
 	ds.Identify.Intents = discordgo.IntentMessageContent
 	// ds.Identify.Presence = discordgo.GatewayStatusUpdate{Status: "online"}
 	ds.UserAgent = "Bookmarker (https://github.com/ErikKalkoken/discord-bookmarker, 0.1.0)"
-	b := bot.New(st, ds, *appIDFlag)
+	b := bot.New(st, ds, *appIDFlag, scheduler.CatchUpPolicy{
+		Mode:    catchUpMode,
+		MaxLate: time.Duration(*catchUpHoursFlag) * time.Hour,
+	})
 	if err := ds.Open(); err != nil {
 		slog.Error("Cannot open the Discord session", "error", err)
 		os.Exit(1)
@@ -122,7 +173,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	b.Start()
+	if err := b.Start(); err != nil {
+		slog.Error("Failed to start bot", "error", err)
+		os.Exit(1)
+	}
+
+	if *backupKeepFlag > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go runScheduledBackups(ctx, dbRO, dataDir, *backupKeepFlag)
+	}
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt)
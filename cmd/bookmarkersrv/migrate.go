@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+// runMigrate runs the migrate command with its arguments, e.g. "status".
+func runMigrate(env commandEnv, args []string) error {
+	if len(args) != 1 || args[0] != "status" {
+		return fmt.Errorf("usage: migrate status")
+	}
+	if _, err := os.Stat(env.dbPath); err != nil {
+		return fmt.Errorf("database not found: %w", err)
+	}
+	dbRW, dbRO, err := storage.OpenDB(env.dsn)
+	if err != nil {
+		return err
+	}
+	defer dbRW.Close()
+	defer dbRO.Close()
+	mm, err := storage.MigrationStatus(dbRO)
+	if err != nil {
+		return err
+	}
+	w := env.out
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+	var pending int
+	for _, m := range mm {
+		appliedAt := "pending"
+		if m.IsApplied() {
+			appliedAt = m.AppliedAt.Local().Format(time.DateTime)
+		} else {
+			pending++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", m.Version, m.Name, appliedAt)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d of %d migrations pending\n", pending, len(mm))
+	return nil
+}
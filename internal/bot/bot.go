@@ -2,12 +2,13 @@
 package bot
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
-	"math"
-	"slices"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,11 +17,15 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/docker/go-units"
 
+	"example/discord-bookmarker/internal/export"
 	"example/discord-bookmarker/internal/queries"
+	"example/discord-bookmarker/internal/scheduler"
 	"example/discord-bookmarker/internal/storage"
+	"example/discord-bookmarker/internal/timeparse"
 )
 
 const (
+	formatDate     = "2006-01-02"
 	formatDateTime = "2006-01-02 15:04"
 	// ColorAqua              = 1752220  // #1ABC9C
 	// ColorBlack             = 2303786  // #23272A
@@ -52,7 +57,21 @@ const (
 	// ColorWhite             = 16777215 // #FFFFFF
 	// colorYellow         = 16705372 // #FEE75C
 	colorOrange         = 0xE67E22 // #E67E22
+	colorRed            = 0xED4245 // #ED4245
 	maxBookmarksPerUser = 100
+	// Max number of choices Discord accepts for an autocomplete response
+	maxAutocompleteChoices = 25
+	// Max time to wait for names from the API when responding to an autocomplete,
+	// which Discord expects within 3 seconds
+	autocompleteFetchTimeout = 1500 * time.Millisecond
+	// Max length of the name of an autocomplete choice
+	maxChoiceNameLength = 100
+	// Max length of a custom ID of a component
+	maxCustomIDLength = 100
+	// Max number of embeds Discord accepts for a message
+	maxEmbedsPerMessage = 10
+	// Max number of options Discord accepts for a select menu
+	maxSelectOptions = 25
 )
 
 // Discord command names for interactions
@@ -69,18 +88,28 @@ const (
 	cmdRemoveBookmarks = "remove"
 	// Set reminder for bookmark
 	cmdRemindBookmarks = "remind"
+	// Search bookmarks
+	cmdSearchBookmarks = "search"
+	// Show and edit user settings
+	cmdSettings = "settings"
 	// Send a test DM to the user
 	cmdTest = "test"
 )
 
 // Discord custom IDs for interactions
 const (
-	idCancelRemove   = "cancel-remove"
-	idNewReminder    = "new-reminder"
-	idRemoveBookmark = "remove-bookmark"
-	idSetReminder    = "set-reminder"
+	idAskRemoveBookmark = "ask-remove-bookmark"
+	idCancelRemove      = "cancel-remove"
+	idCustomReminder    = "custom-reminder:"
+	idNewReminder       = "new-reminder-"
+	idRemoveBookmark    = "remove-bookmark"
+	idReminderTime      = "reminder-time"
+	idSetReminder       = "set-reminder"
 )
 
+// Value of the reminder select option for entering a custom time
+const reminderCustomValue = "custom"
+
 // Discord commands
 var commands = []discordgo.ApplicationCommand{
 	{
@@ -100,6 +129,61 @@ var commands = []discordgo.ApplicationCommand{
 				Description: "List bookmarks",
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Name:        cmdListBookmarks,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Description:  "Only show bookmarks with this tag",
+						Name:         "tag",
+						Autocomplete: true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Description: "Only show bookmarks from this server",
+						Name:        "this-server",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Description: "Only show bookmarks from this channel",
+						Name:        "channel",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Description: "Only show bookmarks for messages by this user",
+						Name:        "author",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Description: "Only show bookmarks with a reminder",
+						Name:        "has-reminder",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Description: "Only show bookmarks with an overdue reminder",
+						Name:        "overdue",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Description: "Only show messages sent on or after this date, e.g. 2025-01-31",
+						Name:        "from",
+						MaxLength:   10,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Description: "Only show messages sent on or before this date, e.g. 2025-12-31",
+						Name:        "to",
+						MaxLength:   10,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Description: "Sort order",
+						Name:        "sort",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Newest saved", Value: "newest-saved"},
+							{Name: "Oldest message", Value: "oldest-message"},
+							{Name: "Due soonest", Value: "due-soonest"},
+						},
+					},
+				},
 			},
 			{
 				Description: "Remove bookmarks",
@@ -107,10 +191,11 @@ var commands = []discordgo.ApplicationCommand{
 				Name:        cmdRemoveBookmarks,
 				Options: []*discordgo.ApplicationCommandOption{
 					{
-						Type:        discordgo.ApplicationCommandOptionInteger,
-						Required:    true,
-						Description: "Bookmark ID",
-						Name:        "bookmark-id",
+						Type:         discordgo.ApplicationCommandOptionInteger,
+						Required:     true,
+						Description:  "Bookmark ID",
+						Name:         "bookmark-id",
+						Autocomplete: true,
 					},
 				},
 			},
@@ -120,13 +205,44 @@ var commands = []discordgo.ApplicationCommand{
 				Name:        cmdRemindBookmarks,
 				Options: []*discordgo.ApplicationCommandOption{
 					{
-						Type:        discordgo.ApplicationCommandOptionInteger,
+						Type:         discordgo.ApplicationCommandOptionInteger,
+						Required:     true,
+						Description:  "Bookmark ID",
+						Name:         "bookmark-id",
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Description: "Search bookmarks",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        cmdSearchBookmarks,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
 						Required:    true,
-						Description: "Bookmark ID",
-						Name:        "bookmark-id",
+						Description: "Words to search for",
+						Name:        "query",
 					},
 				},
 			},
+			{
+				Description: "Show and edit your settings",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        cmdSettings,
+				Options: append([]*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Description: "Your timezone, e.g. Europe/Berlin",
+						Name:        "timezone",
+					},
+				}, settingsOptionsDigest...),
+			},
+			commandOptionTag,
+			commandOptionExport,
+			commandOptionImport,
+			commandOptionForgetMe,
+			commandOptionClear,
 			{
 				Description: "Send test DM",
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
@@ -170,14 +286,6 @@ type discordMessage struct {
 	timestamp time.Time
 }
 
-func (x discordMessage) UID() string {
-	return messageUID(x.guildID, x.channelID, x.messageID)
-}
-
-func messageUID(guildID, channelID, messageID string) string {
-	return fmt.Sprintf("%s-%s-%s", guildID, channelID, messageID)
-}
-
 type User struct {
 	ID        string
 	Name      string
@@ -185,31 +293,45 @@ type User struct {
 }
 
 type Bot struct {
-	appID string
-	ds    *discordgo.Session
-	st    *storage.Storage
+	appID       string
+	catchUp     scheduler.CatchUpPolicy
+	ds          *discordgo.Session
+	digestSched *scheduler.Scheduler // schedules the digests by user ID
+	owner       string               // identifies this bot when claiming reminders
+	sched       *scheduler.Scheduler
+	st          *storage.Storage
 
-	messageCache sync.Map
+	channelCache sync.Map
 	userCache    sync.Map
 }
 
 // New registered a Discord bot with all interactions and returns it.
-func New(st *storage.Storage, ds *discordgo.Session, appID string) *Bot {
+// The catch-up policy defines how reminders are sent which were missed, e.g. during downtime.
+func New(st *storage.Storage, ds *discordgo.Session, appID string, catchUp scheduler.CatchUpPolicy) *Bot {
 	b := &Bot{
-		appID: appID,
-		st:    st,
-		ds:    ds,
+		appID:   appID,
+		catchUp: catchUp,
+		st:      st,
+		ds:      ds,
+		owner:   makeOwnerID(),
 	}
+	b.sched = scheduler.New(scheduler.RealClock{}, catchUp, b.sendDueReminders)
+	st.OnDueAtChanged(b.sched.Set)
+	b.digestSched = scheduler.New(scheduler.RealClock{}, scheduler.CatchUpPolicy{}, func(batch scheduler.Batch) {
+		b.sendDigests(batch.IDs(), batch.Now)
+	})
 	ds.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		slog.Info("Bot is up!")
 	})
 	ds.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		err := func() error {
 			switch i.Type {
-			case discordgo.InteractionApplicationCommand:
+			case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
 				return b.handleApplicationCommand(i)
 			case discordgo.InteractionMessageComponent:
 				return b.handleMessageComponent(i)
+			case discordgo.InteractionModalSubmit:
+				return b.handleModalSubmit(i)
 			}
 			return fmt.Errorf("unexpected interaction type %d", i.Type)
 		}()
@@ -220,52 +342,58 @@ func New(st *storage.Storage, ds *discordgo.Session, appID string) *Bot {
 	return b
 }
 
-func (b *Bot) Start() {
-	ticker := time.NewTicker(15 * time.Second)
+// Start starts the background jobs of the bot, e.g. sending reminders.
+func (b *Bot) Start() error {
+	reminders, err := b.st.ListReminders()
+	if err != nil {
+		return err
+	}
+	for id, dueAt := range reminders {
+		b.sched.Set(id, dueAt)
+	}
+	slog.Info("Reminders scheduled", "count", len(reminders))
+	digests, err := b.st.ListDigestUserSettings()
+	if err != nil {
+		return err
+	}
+	for _, us := range digests {
+		if err := b.scheduleDigest(us); err != nil {
+			slog.Error("Failed to schedule digest", "user", us.UserID, "error", err)
+		}
+	}
+	slog.Info("Digests scheduled", "count", len(digests))
+	go b.sched.Run(context.Background())
+	go b.digestSched.Run(context.Background())
 	go func() {
+		ticker := time.NewTicker(reminderSweepInterval)
 		for {
 			<-ticker.C
-			bookmarks, err := b.st.ListDueBookmarks()
-			if err != nil {
-				slog.Error("Failed to fetch due bookmarks", "error", err)
-				continue
+			if err := b.sweepDueReminders(); err != nil {
+				slog.Error("Failed to sweep due reminders", "error", err)
 			}
-			if len(bookmarks) == 0 {
-				continue
-			}
-			for _, r := range bookmarks {
-				author, err := b.fetchUser(r.AuthorID)
-				if err != nil {
-					panic(err)
-				}
-				err = b.sendDM(
-					r.UserID,
-					fmt.Sprintf("You asked me to remind you about this message from %s:", author.Name),
-					[]*discordgo.MessageEmbed{
-						b.makeEmbedFromBookmark(r, makeEmbedFromBookmarkOpts{hideDue: true}),
-					})
-				if err != nil {
-					slog.Error("Failed to send DM", "error", err)
-					continue
-				}
-				slog.Info("Reminder sent", "user", r.UserID, "id", r.ID)
-				if err := b.st.RemoveReminder(r.ID); err != nil {
-					slog.Error("Failed to reset bookmark", "error", err)
-					continue
-				}
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		for {
+			<-ticker.C
+			if _, err := b.st.DeleteExpiredPendingBookmarks(); err != nil {
+				slog.Error("Failed to delete expired pending bookmarks", "error", err)
 			}
 		}
 	}()
+	return nil
 }
 
-func (b *Bot) sendDM(userID string, content string, embeds []*discordgo.MessageEmbed) error {
+func (b *Bot) sendDM(userID string, content string, embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) error {
 	c, err := b.ds.UserChannelCreate(userID)
 	if err != nil {
 		return err
 	}
 	if _, err := b.ds.ChannelMessageSendComplex(c.ID, &discordgo.MessageSend{
-		Content: content,
-		Embeds:  embeds,
+		Content:    content,
+		Embeds:     embeds,
+		Components: components,
 	}); err != nil {
 		return err
 	}
@@ -311,6 +439,30 @@ func interactionUserID(i *discordgo.InteractionCreate) (string, error) {
 	return "", fmt.Errorf("no user found for interaction")
 }
 
+// respondDeferred responds to a slow command with an ephemeral message returned by f.
+// The response is deferred first, so that f can take longer than Discord's timeout.
+// action describes what f does for the error message, e.g. "exporting your bookmarks".
+func (b *Bot) respondDeferred(i *discordgo.InteractionCreate, action string, f func() (string, error)) error {
+	err := b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	content, err := f()
+	if err != nil {
+		slog.Error("Command failed", "action", action, "error", err)
+		content = fmt.Sprintf("Sorry, something went wrong while %s", action)
+	}
+	_, err = b.ds.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+	return err
+}
+
 func (b *Bot) handleApplicationCommand(i *discordgo.InteractionCreate) error {
 	createMessageContext := func() discordMessage {
 		data := i.ApplicationCommandData()
@@ -351,36 +503,7 @@ func (b *Bot) handleApplicationCommand(i *discordgo.InteractionCreate) error {
 							discordgo.SelectMenu{
 								CustomID:    customID,
 								Placeholder: "Choose reminder duration",
-								Options: []discordgo.SelectMenuOption{
-									{
-										Label: "10 seconds",
-										Value: "10",
-									},
-									{
-										Label: "1 hour",
-										Value: "3600",
-									},
-									{
-										Label: "3 hours",
-										Value: "10800",
-									},
-									{
-										Label: "1 day",
-										Value: "86400",
-									},
-									{
-										Label: "3 days",
-										Value: "259200",
-									},
-									{
-										Label: "1 week",
-										Value: "604800",
-									},
-									{
-										Label: "Never",
-										Value: "0",
-									},
-								},
+								Options:     b.makeReminderOptions(bm.UserID),
 							},
 						},
 					},
@@ -395,6 +518,16 @@ func (b *Bot) handleApplicationCommand(i *discordgo.InteractionCreate) error {
 		return err
 	}
 	data := i.ApplicationCommandData()
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		o := findFocusedOption(data.Options)
+		if o == nil {
+			return fmt.Errorf("no focused option: %+v", data.Options)
+		}
+		if o.Name == "tag" {
+			return b.respondWithTagChoices(i, userID, fmt.Sprint(o.Value))
+		}
+		return b.respondWithBookmarkChoices(i, userID, fmt.Sprint(o.Value))
+	}
 	name := data.Name
 	switch name {
 	case cmdCreateBookmark:
@@ -427,12 +560,26 @@ func (b *Bot) handleApplicationCommand(i *discordgo.InteractionCreate) error {
 		} else {
 			s = "updated"
 		}
-		return respondWithMessage(fmt.Sprintf("Bookmark #%d %s", id, s))
+		return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: makeBookmarkSavedData(fmt.Sprintf("Bookmark #%d %s", id, s), id),
+		})
 
 	case cmdCreateBookmarkWithReminder:
 		m := createMessageContext()
-		b.messageCache.Store(m.UID(), m)
-		return responseWithReminderSelect(idNewReminder, queries.Bookmark{
+		pendingID, err := b.st.CreatePendingBookmark(storage.UpdateOrCreateBookmarkParams{
+			AuthorID:  m.authorID,
+			ChannelID: m.channelID,
+			Content:   m.content,
+			GuildID:   m.guildID,
+			MessageID: m.messageID,
+			Timestamp: m.timestamp,
+			UserID:    userID,
+		})
+		if err != nil {
+			return err
+		}
+		return responseWithReminderSelect(fmt.Sprintf("%s%d", idNewReminder, pendingID), queries.Bookmark{
 			AuthorID:  m.authorID,
 			ChannelID: m.channelID,
 			Content:   m.content,
@@ -449,90 +596,35 @@ func (b *Bot) handleApplicationCommand(i *discordgo.InteractionCreate) error {
 		cmdOption := data.Options[0]
 		switch cmdOption.Name {
 		case cmdListBookmarks:
-			bookmarks, err := b.st.ListBookmarksForUser(userID)
-			if err != nil {
-				return err
-			}
-			if len(bookmarks) == 0 {
-				return respondWithMessage("No bookmarked messages yet")
-			}
-			err = b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Flags: discordgo.MessageFlagsEphemeral,
-				},
-			})
+			f, message, err := b.makeListFilter(i, userID, cmdOption.Options)
 			if err != nil {
 				return err
 			}
-			const maxBookmarksPerPage = 10
-			pages := int(math.Ceil(float64(len(bookmarks)) / maxBookmarksPerPage))
-			page := 1
-			for chunk := range slices.Chunk(bookmarks, maxBookmarksPerPage) {
-				content := fmt.Sprintf("%d bookmarked messages", len(bookmarks))
-				if pages > 1 {
-					content += fmt.Sprintf(" [%d/%d]", page, pages)
-				}
-				embeds := make([]*discordgo.MessageEmbed, 0)
-				for _, bm := range chunk {
-					embeds = append(embeds, b.makeEmbedFromBookmark(bm, makeEmbedFromBookmarkOpts{}))
-				}
-				_, err = b.ds.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
-					Content: content,
-					Embeds:  embeds,
-					Flags:   discordgo.MessageFlagsEphemeral,
-				})
-				if err != nil {
-					return err
-				}
-				page++
+			if message != "" {
+				return respondWithMessage(message)
 			}
-			return nil
+			return b.respondWithListPage(i, userID, f, 0, false)
 
 		case cmdRemoveBookmarks:
 			if len(cmdOption.Options) != 1 {
 				return fmt.Errorf("expected one option only: %+v", cmdOption.Options)
 			}
 			id := cmdOption.Options[0].IntValue()
-			bm, err := b.st.GetBookmark(id)
-			if errors.Is(err, sql.ErrNoRows) {
-				return respondWithMessage(fmt.Sprintf("No bookmark found with ID #%d", id))
-			} else if err != nil {
+			rd, err := b.makeRemoveBookmarkMessage(id, userID)
+			if err != nil {
 				return err
 			}
-			err = b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Are you sure you want to remove this bookmark?",
-					Flags:   discordgo.MessageFlagsEphemeral,
-					Embeds: []*discordgo.MessageEmbed{
-						b.makeEmbedFromBookmark(bm, makeEmbedFromBookmarkOpts{}),
-					},
-					Components: []discordgo.MessageComponent{
-						discordgo.ActionsRow{
-							Components: []discordgo.MessageComponent{
-								discordgo.Button{
-									Label:    "Remove",
-									Style:    discordgo.DangerButton,
-									CustomID: fmt.Sprintf("%s%d", idRemoveBookmark, id),
-								},
-								discordgo.Button{
-									Label:    "Cancel",
-									CustomID: idCancelRemove,
-								},
-							},
-						},
-					},
-				},
+				Data: rd,
 			})
-			return err
 
 		case cmdRemindBookmarks:
 			if len(cmdOption.Options) != 1 {
 				return fmt.Errorf("expected one option only: %+v", cmdOption.Options)
 			}
 			id := cmdOption.Options[0].IntValue()
-			bm, err := b.st.GetBookmark(id)
+			bm, err := b.st.GetBookmarkForUser(id, userID)
 			if errors.Is(err, sql.ErrNoRows) {
 				return respondWithMessage(fmt.Sprintf("No bookmark found with ID #%d", id))
 			} else if err != nil {
@@ -540,8 +632,102 @@ func (b *Bot) handleApplicationCommand(i *discordgo.InteractionCreate) error {
 			}
 			return responseWithReminderSelect(fmt.Sprintf("%s%d", idSetReminder, bm.ID), bm)
 
+		case cmdSearchBookmarks:
+			if len(cmdOption.Options) != 1 {
+				return fmt.Errorf("expected one option only: %+v", cmdOption.Options)
+			}
+			query := cmdOption.Options[0].StringValue()
+			const maxSearchResults = 10
+			results, err := b.st.SearchBookmarksForUser(userID, query, maxSearchResults)
+			if err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				return respondWithMessage(fmt.Sprintf("No bookmarks found for **%s**", query))
+			}
+			embeds := make([]*discordgo.MessageEmbed, 0)
+			for _, r := range results {
+				embeds = append(embeds, b.makeEmbedFromBookmark(r.Bookmark, makeEmbedFromBookmarkOpts{snippet: r.Snippet}))
+			}
+			var content string
+			if len(results) == maxSearchResults {
+				content = fmt.Sprintf("Top %d bookmarks for **%s**", len(results), query)
+			} else {
+				content = fmt.Sprintf("%d bookmarks found for **%s**", len(results), query)
+			}
+			return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: content,
+					Embeds:  embeds,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+
+		case cmdTag:
+			content, err := b.handleTagCommand(userID, cmdOption)
+			if err != nil {
+				return err
+			}
+			return respondWithMessage(content)
+
+		case cmdSettings:
+			us, err := b.st.GetUserSettings(userID)
+			if err != nil {
+				return err
+			}
+			if len(cmdOption.Options) > 0 {
+				if o := findOption(cmdOption.Options, "timezone"); o != nil {
+					tz := o.StringValue()
+					if _, err := time.LoadLocation(tz); err != nil || tz == "" {
+						return respondWithMessage(fmt.Sprintf(
+							"Unknown timezone **%s**. Please use a name from the IANA time zone database, e.g. Europe/Berlin.", tz,
+						))
+					}
+					us.Timezone = tz
+				}
+				updateDigestFromOptions(&us, cmdOption.Options)
+				if err := b.updateUserSettings(us); err != nil {
+					return err
+				}
+			}
+			return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: makeSettingsMessage(us),
+			})
+
+		case cmdExport:
+			return b.respondDeferred(i, "exporting your bookmarks", func() (string, error) {
+				return b.handleExportCommand(userID, cmdOption)
+			})
+
+		case cmdForgetMe:
+			var withExport bool
+			if o := findOption(cmdOption.Options, "export"); o != nil {
+				withExport = o.BoolValue()
+			}
+			return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: makeForgetMeMessage(withExport),
+			})
+
+		case cmdClear:
+			rd, err := makeClearMessage(cmdOption)
+			if err != nil {
+				return err
+			}
+			return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: rd,
+			})
+
+		case cmdImport:
+			return b.respondDeferred(i, "importing your bookmarks", func() (string, error) {
+				return b.handleImportCommand(userID, data, cmdOption)
+			})
+
 		case cmdTest:
-			err := b.sendDM(userID, "Hi, there! I am ready to assist you.", nil)
+			err := b.sendDM(userID, "Hi, there! I am ready to assist you.", nil, nil)
 			if err != nil {
 				return err
 			}
@@ -554,17 +740,113 @@ func (b *Bot) handleApplicationCommand(i *discordgo.InteractionCreate) error {
 	return fmt.Errorf("unhandled application command %s", name)
 }
 
+// respondWithBookmarkChoices responds to an autocomplete interaction
+// with the user's bookmarks matching the input of the focused option.
+// Bookmarks match by ID prefix or content.
+func (b *Bot) respondWithBookmarkChoices(i *discordgo.InteractionCreate, userID string, input string) error {
+	input = strings.TrimPrefix(strings.TrimSpace(input), "#")
+	// labels need names from the API, so only the bookmarks shown as choices are loaded
+	bookmarks, err := b.st.ListBookmarksMatchingForUser(userID, input, maxAutocompleteChoices)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), autocompleteFetchTimeout)
+	defer cancel()
+	b.fetchLabelNames(ctx, bookmarks)
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(bookmarks))
+	for _, bm := range bookmarks {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  b.makeBookmarkLabel(bm),
+			Value: bm.ID,
+		})
+	}
+	return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+}
+
+// fetchLabelNames fetches the names of the authors and channels of bookmarks concurrently into the caches,
+// so that [Bot.makeBookmarkLabel] can show them.
+// It returns when all names are fetched or when ctx is done.
+func (b *Bot) fetchLabelNames(ctx context.Context, bookmarks []queries.Bookmark) {
+	var wg sync.WaitGroup
+	users := make(map[string]bool)
+	channels := make(map[string]bool)
+	for _, bm := range bookmarks {
+		if !users[bm.AuthorID] {
+			users[bm.AuthorID] = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := b.fetchUser(bm.AuthorID, discordgo.WithContext(ctx)); err != nil {
+					slog.Warn("Failed to fetch user", "userID", bm.AuthorID, "error", err)
+				}
+			}()
+		}
+		if !channels[bm.ChannelID] {
+			channels[bm.ChannelID] = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.fetchChannelName(bm.ChannelID, discordgo.WithContext(ctx))
+			}()
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	// requests can wait for rate limits without observing ctx
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// makeBookmarkLabel returns a short one line description of a bookmark,
+// e.g. for autocomplete choices.
+// It only uses cached names and leaves out names not cached, so it never calls the API.
+// Use [Bot.fetchLabelNames] to fill the caches first.
+func (b *Bot) makeBookmarkLabel(bm queries.Bookmark) string {
+	s := fmt.Sprintf("#%d", bm.ID)
+	if user, ok := b.cachedUser(bm.AuthorID); ok {
+		s += " · " + user.Name
+	}
+	if name, ok := b.cachedChannelName(bm.ChannelID); ok && name != "" {
+		s += fmt.Sprintf(" in #%s", name)
+	}
+	content := strings.Join(strings.Fields(bm.Content), " ")
+	if content != "" {
+		s += ": " + content
+	}
+	return truncateString(s, maxChoiceNameLength)
+}
+
+// truncateString returns s truncated to at most maxRunes runes.
+// Truncated strings end with an ellipsis.
+func truncateString(s string, maxRunes int) string {
+	r := []rune(s)
+	if len(r) <= maxRunes {
+		return s
+	}
+	return string(r[:maxRunes-1]) + "…"
+}
+
 func (b *Bot) handleMessageComponent(i *discordgo.InteractionCreate) error {
-	respondWithUpdate := func(content string) error {
+	respondWithUpdateData := func(data *discordgo.InteractionResponseData) error {
 		err := b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseUpdateMessage,
-			Data: &discordgo.InteractionResponseData{
-				Content: content,
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
+			Data: data,
 		})
 		return err
 	}
+	respondWithUpdate := func(content string) error {
+		return respondWithUpdateData(makeUpdateData(content))
+	}
 	if i.Type != discordgo.InteractionMessageComponent {
 		return nil
 	}
@@ -574,7 +856,14 @@ func (b *Bot) handleMessageComponent(i *discordgo.InteractionCreate) error {
 	}
 	data := i.MessageComponentData()
 	customID := data.CustomID
-	if customID == idNewReminder {
+	if x, found := strings.CutPrefix(customID, idNewReminder); found {
+		pendingID, err := strconv.Atoi(x)
+		if err != nil {
+			return err
+		}
+		if data.Values[0] == reminderCustomValue {
+			return b.respondWithReminderModal(i, customID)
+		}
 		seconds, err := strconv.Atoi(data.Values[0])
 		if err != nil {
 			return err
@@ -583,46 +872,123 @@ func (b *Bot) handleMessageComponent(i *discordgo.InteractionCreate) error {
 		if seconds > 0 {
 			dueAt = time.Now().UTC().Add(time.Second * time.Duration(seconds))
 		}
-		mr := i.Message.MessageReference
-		uid := messageUID(mr.GuildID, mr.ChannelID, mr.MessageID)
-		x, _ := b.messageCache.Load(uid)
-		b.messageCache.Delete(uid)
-		m := x.(discordMessage)
-		id, created, err := b.st.UpdateOrCreateBookmark(storage.UpdateOrCreateBookmarkParams{
-			AuthorID:  m.authorID,
-			ChannelID: m.channelID,
-			Content:   m.content,
-			DueAt:     dueAt,
-			GuildID:   m.guildID,
-			MessageID: m.messageID,
-			Timestamp: m.timestamp,
-			UserID:    userID,
+		rd, err := b.createBookmarkWithReminder(int64(pendingID), userID, dueAt)
+		if err != nil {
+			return err
+		}
+		return respondWithUpdateData(rd)
+
+	} else if strings.HasPrefix(customID, idSettings) {
+		us, err := b.st.GetUserSettings(userID)
+		if err != nil {
+			return err
+		}
+		if err := updateSettingsFromSelect(&us, customID, data.Values[0]); err != nil {
+			return err
+		}
+		if err := b.updateUserSettings(us); err != nil {
+			return err
+		}
+		return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: makeSettingsMessage(us),
 		})
+
+	} else if x, found := strings.CutPrefix(customID, idSetRecurrence); found {
+		id, err := strconv.Atoi(x)
 		if err != nil {
 			return err
 		}
-		var s1, s2 string
-		if created {
-			s1 = "created"
-		} else {
-			s1 = "updated"
+		value := data.Values[0]
+		if value == recurrenceCustomValue {
+			return b.respondWithRecurrenceModal(i, int64(id))
 		}
-		if seconds > 0 {
-			s2 = fmt.Sprintf("Will remind you in %s.", units.HumanDuration(time.Until(dueAt)))
-		} else {
-			s2 = "Will not remind you."
+		if value == recurrenceNoneValue {
+			value = ""
 		}
-		return respondWithUpdate(fmt.Sprintf("Bookmark #%d %s. %s", id, s1, s2))
+		rd, err := b.setRecurrence(int64(id), userID, value)
+		if err != nil {
+			return err
+		}
+		return respondWithUpdateData(rd)
+
+	} else if page, f, found := parseListPageID(customID); found {
+		return b.respondWithListPage(i, userID, f, page, true)
+
+	} else if action, id, found := parseReminderButtonID(customID); found {
+		rd, err := b.handleReminderButton(i, userID, action, id)
+		if err != nil {
+			return err
+		}
+		return respondWithUpdateData(rd)
 
-	} else if customID == idCancelRemove {
+	} else if x, found := strings.CutPrefix(customID, idEditTags); found {
+		id, err := strconv.Atoi(x)
+		if err != nil {
+			return err
+		}
+		return b.respondWithTagsModal(i, userID, int64(id))
+
+	} else if x, found := strings.CutPrefix(customID, idEditNote); found {
+		id, err := strconv.Atoi(x)
+		if err != nil {
+			return err
+		}
+		return b.respondWithNoteModal(i, userID, int64(id))
+
+	} else if customID == idCancelRemove || customID == idCancelForgetMe {
 		return respondWithUpdate("Canceled")
 
+	} else if x, found := strings.CutPrefix(customID, idForgetMe); found {
+		return b.handleForgetMeButton(i, userID, x == "1")
+
+	} else if customID == idListRemove {
+		rd, err := makeRemoveSelectedMessage(data.Values)
+		if err != nil {
+			return err
+		}
+		return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: rd,
+		})
+
+	} else if x, found := strings.CutPrefix(customID, idRemoveSelected); found {
+		content, err := b.handleRemoveSelectedButton(userID, x)
+		if err != nil {
+			return err
+		}
+		return respondWithUpdate(content)
+
+	} else if x, found := strings.CutPrefix(customID, idClear); found {
+		content, err := b.handleClearButton(userID, x)
+		if err != nil {
+			return err
+		}
+		return respondWithUpdate(content)
+
+	} else if x, found := strings.CutPrefix(customID, idAskRemoveBookmark); found {
+		id, err := strconv.Atoi(x)
+		if err != nil {
+			return err
+		}
+		rd, err := b.makeRemoveBookmarkMessage(int64(id), userID)
+		if err != nil {
+			return err
+		}
+		return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: rd,
+		})
+
 	} else if x, found := strings.CutPrefix(customID, idRemoveBookmark); found {
 		id, err := strconv.Atoi(x)
 		if err != nil {
 			return err
 		}
-		if err := b.st.DeleteBookmark(int64(id)); err != nil {
+		err = b.st.DeleteBookmark(int64(id), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return respondWithUpdate(fmt.Sprintf("No bookmark found with ID #%d", id))
+		} else if err != nil {
 			return err
 		}
 		return respondWithUpdate(fmt.Sprintf("Bookmark #%d removed", id))
@@ -631,6 +997,9 @@ func (b *Bot) handleMessageComponent(i *discordgo.InteractionCreate) error {
 		if err != nil {
 			return err
 		}
+		if data.Values[0] == reminderCustomValue {
+			return b.respondWithReminderModal(i, customID)
+		}
 		seconds, err := strconv.Atoi(data.Values[0])
 		if err != nil {
 			return err
@@ -639,18 +1008,259 @@ func (b *Bot) handleMessageComponent(i *discordgo.InteractionCreate) error {
 		if seconds > 0 {
 			dueAt = time.Now().UTC().Add(time.Second * time.Duration(seconds))
 		}
-		if err := b.st.SetReminder(int64(id), dueAt); err != nil {
+		rd, err := b.setReminder(int64(id), userID, dueAt)
+		if err != nil {
 			return err
 		}
-		var s string
-		if seconds > 0 {
-			s = "set"
+		return respondWithUpdateData(rd)
+	}
+	return fmt.Errorf("unhandled custom ID %s", customID)
+}
+
+// createBookmarkWithReminder creates a bookmark from the pending bookmark of a reminder prompt
+// and returns the response.
+// No reminder is set when dueAt is zero.
+func (b *Bot) createBookmarkWithReminder(pendingID int64, userID string, dueAt time.Time) (*discordgo.InteractionResponseData, error) {
+	arg, err := b.st.GetPendingBookmark(pendingID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return makeUpdateData("This prompt expired. Please bookmark the message again."), nil
+	} else if err != nil {
+		return nil, err
+	}
+	arg.DueAt = dueAt
+	id, created, err := b.st.UpdateOrCreateBookmark(arg)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.st.DeletePendingBookmark(pendingID); err != nil {
+		slog.Warn("Failed to delete pending bookmark", "id", pendingID, "error", err)
+	}
+	var s1 string
+	if created {
+		s1 = "created"
+	} else {
+		s1 = "updated"
+	}
+	if dueAt.IsZero() {
+		return makeBookmarkSavedData(fmt.Sprintf("Bookmark #%d %s. Will not remind you.", id, s1), id), nil
+	}
+	return makeReminderSetData(fmt.Sprintf(
+		"Bookmark #%d %s. Will remind you %s.", id, s1, formatDueAt(dueAt, b.fetchUserSettings(userID)),
+	), id), nil
+}
+
+// setReminder sets the reminder for a bookmark of a user and returns the response.
+// The reminder is removed when dueAt is zero.
+func (b *Bot) setReminder(id int64, userID string, dueAt time.Time) (*discordgo.InteractionResponseData, error) {
+	err := b.st.SetReminder(id, userID, dueAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return makeUpdateData(fmt.Sprintf("No bookmark found with ID #%d", id)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	if dueAt.IsZero() {
+		return makeUpdateData(fmt.Sprintf("Reminder removed for bookmark #%d", id)), nil
+	}
+	return makeReminderSetData(fmt.Sprintf(
+		"Reminder set for bookmark #%d. Will remind you %s.", id, formatDueAt(dueAt, b.fetchUserSettings(userID)),
+	), id), nil
+}
+
+// makeRemoveBookmarkMessage returns the message asking a user to confirm removing a bookmark.
+func (b *Bot) makeRemoveBookmarkMessage(id int64, userID string) (*discordgo.InteractionResponseData, error) {
+	bm, err := b.st.GetBookmarkForUser(id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return makeUpdateData(fmt.Sprintf("No bookmark found with ID #%d", id)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	rd := makeRemoveConfirmation(
+		"Are you sure you want to remove this bookmark?",
+		fmt.Sprintf("%s%d", idRemoveBookmark, id),
+	)
+	rd.Embeds = []*discordgo.MessageEmbed{
+		b.makeEmbedFromBookmark(bm, makeEmbedFromBookmarkOpts{}),
+	}
+	return rd, nil
+}
+
+// makeBookmarkSavedData returns the data for a message after a bookmark was saved.
+// It includes buttons for editing the bookmark.
+func makeBookmarkSavedData(content string, bookmarkID int64) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Components: []discordgo.MessageComponent{
+			makeEditBookmarkButtons(bookmarkID),
+		},
+	}
+}
+
+// makeEditBookmarkButtons returns a row of buttons for editing a bookmark.
+func makeEditBookmarkButtons(bookmarkID int64) discordgo.ActionsRow {
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			makeEditTagsButton(bookmarkID),
+			makeEditNoteButton(bookmarkID),
+		},
+	}
+}
+
+// makeUpdateData returns the data for updating a message with new content.
+func makeUpdateData(content string) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	}
+}
+
+// respondWithReminderModal responds with a modal for entering a custom reminder time.
+// The custom ID of the originating select is passed on to the modal.
+func (b *Bot) respondWithReminderModal(i *discordgo.InteractionCreate, customID string) error {
+	return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: idCustomReminder + customID,
+			Title:    "Custom reminder",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    idReminderTime,
+							Label:       "When should I remind you?",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "e.g. in 45 minutes, tomorrow 9am, next friday 17:30",
+							Required:    true,
+							MaxLength:   100,
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func (b *Bot) handleModalSubmit(i *discordgo.InteractionCreate) error {
+	respondWithUpdateData := func(data *discordgo.InteractionResponseData) error {
+		err := b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: data,
+		})
+		return err
+	}
+	respondWithMessage := func(content string) error {
+		err := b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return err
+	}
+	userID, err := interactionUserID(i)
+	if err != nil {
+		return err
+	}
+	data := i.ModalSubmitData()
+	customID := data.CustomID
+	if target, found := strings.CutPrefix(customID, idCustomReminder); found {
+		input := modalTextInputValue(data, idReminderTime)
+		dueAt, err := timeparse.Parse(input, time.Now().In(b.userLocation(userID)))
+		if errors.Is(err, timeparse.ErrInvalid) {
+			return respondWithMessage(fmt.Sprintf(
+				"Sorry, I did not understand **%s**. Try something like \"in 45 minutes\", \"tomorrow 9am\" or \"2025-12-24 18:00\".",
+				input,
+			))
+		} else if err != nil {
+			return err
+		}
+		if !dueAt.After(time.Now()) {
+			return respondWithMessage(fmt.Sprintf(
+				"**%s** is in the past. Please enter a time in the future.", dueAt.Format(formatDateTime),
+			))
+		}
+		dueAt = dueAt.UTC()
+		var rd *discordgo.InteractionResponseData
+		if x, found := strings.CutPrefix(target, idNewReminder); found {
+			pendingID, err2 := strconv.Atoi(x)
+			if err2 != nil {
+				return err2
+			}
+			rd, err = b.createBookmarkWithReminder(int64(pendingID), userID, dueAt)
+		} else if x, found := strings.CutPrefix(target, idSetReminder); found {
+			id, err2 := strconv.Atoi(x)
+			if err2 != nil {
+				return err2
+			}
+			rd, err = b.setReminder(int64(id), userID, dueAt)
 		} else {
-			s = "removed"
+			return fmt.Errorf("unhandled custom reminder target %s", target)
+		}
+		if err != nil {
+			return err
+		}
+		return respondWithUpdateData(rd)
+
+	} else if x, found := strings.CutPrefix(customID, idCustomRecurrence); found {
+		id, err := strconv.Atoi(x)
+		if err != nil {
+			return err
+		}
+		input := modalTextInputValue(data, idRecurrenceRule)
+		if _, err := storage.ParseRecurrenceRule(input); err != nil {
+			return respondWithMessage(fmt.Sprintf(
+				"Sorry, **%s** is not a valid rule. Try something like \"weekly:mo,fr\" or \"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU\".",
+				input,
+			))
+		}
+		rd, err := b.setRecurrence(int64(id), userID, input)
+		if err != nil {
+			return err
+		}
+		return respondWithUpdateData(rd)
+
+	} else if x, found := strings.CutPrefix(customID, idTagsModal); found {
+		id, err := strconv.Atoi(x)
+		if err != nil {
+			return err
+		}
+		rd, err := b.setTags(int64(id), userID, modalTextInputValue(data, idTagsInput))
+		if err != nil {
+			return err
+		}
+		return respondWithUpdateData(rd)
+
+	} else if x, found := strings.CutPrefix(customID, idNoteModal); found {
+		id, err := strconv.Atoi(x)
+		if err != nil {
+			return err
 		}
-		return respondWithUpdate(fmt.Sprintf("Reminder %s for bookmark #%d", s, id))
+		rd, err := b.setNote(int64(id), userID, modalTextInputValue(data, idNoteInput))
+		if err != nil {
+			return err
+		}
+		return respondWithUpdateData(rd)
 	}
-	return fmt.Errorf("unhandled custom ID %s", customID)
+	return fmt.Errorf("unhandled modal custom ID %s", customID)
+}
+
+// modalTextInputValue returns the value of the text input with the given custom ID
+// or an empty string if not found.
+func modalTextInputValue(data discordgo.ModalSubmitInteractionData, customID string) string {
+	for _, c := range data.Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c2 := range row.Components {
+			ti, ok := c2.(*discordgo.TextInput)
+			if ok && ti.CustomID == customID {
+				return ti.Value
+			}
+		}
+	}
+	return ""
 }
 
 // func (b *Bot) removeCommands() error {
@@ -677,50 +1287,143 @@ func (b *Bot) handleMessageComponent(i *discordgo.InteractionCreate) error {
 
 type makeEmbedFromBookmarkOpts struct {
 	hideDue bool
+	late    time.Duration // how late a reminder is, shown when set
+	snippet string        // shown instead of the full content when set
 }
 
 func (b *Bot) makeEmbedFromBookmark(bm queries.Bookmark, opts makeEmbedFromBookmarkOpts) *discordgo.MessageEmbed {
-	var guildID string
-	if bm.GuildID == "" {
-		guildID = "@me"
-	} else {
-		guildID = bm.GuildID
-	}
 	user, err := b.fetchUser(bm.AuthorID)
 	if err != nil {
-		panic(err)
+		slog.Warn("Failed to fetch user", "userID", bm.AuthorID, "error", err)
+		user = User{ID: bm.AuthorID, Name: "Unknown user"}
+	}
+	messageLink := makeMessageLink(bm)
+	footer := fmt.Sprintf("#%d", bm.ID)
+	if tags := b.makeTagsFooter(bm.ID); tags != "" {
+		footer += " · " + tags
 	}
-	messageLink := fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, bm.ChannelID, bm.MessageID)
 	me := &discordgo.MessageEmbed{
 		Author: &discordgo.MessageEmbedAuthor{
 			Name:    user.Name,
 			IconURL: user.AvatarURL,
 		},
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("#%d", bm.ID),
+			Text: footer,
 		},
-		Description: fmt.Sprintf("%s\n\n%s", bm.Content, messageLink),
+		Description: fmt.Sprintf("%s\n\n%s", cmp.Or(opts.snippet, bm.Content), messageLink),
 		Timestamp:   bm.Timestamp.Format(time.RFC3339),
 	}
+	if bm.Note.Valid {
+		me.Fields = append(me.Fields, &discordgo.MessageEmbedField{
+			Name:  "📝 Note",
+			Value: bm.Note.String,
+		})
+	}
+	if opts.late > 0 {
+		me.Description += fmt.Sprintf("\n\n⏰ **%s late**", units.HumanDuration(opts.late))
+	}
 	if !opts.hideDue && bm.DueAt.Valid {
-		me.Description += fmt.Sprintf("\n\n🕘 **Due in %s**", units.HumanDuration(time.Until(bm.DueAt.Time)))
+		us := b.fetchUserSettings(bm.UserID)
+		me.Description += fmt.Sprintf("\n\n🕘 **Due %s**", formatDueAt(bm.DueAt.Time, us))
+		if t := us.DeliveryTime(bm.DueAt.Time); !t.Equal(bm.DueAt.Time) {
+			me.Description += fmt.Sprintf("\n🌙 Quiet hours: will be delivered at %s", formatDeliveryTime(t, bm.DueAt.Time, us))
+		}
 		me.Color = colorOrange
+		if d, err := b.st.GetFailedDelivery(bm.ID); err == nil {
+			me.Description += fmt.Sprintf(
+				"\n⚠️ **Reminder could not be sent** after %d attempts: %s", d.Attempts, d.Error,
+			)
+			me.Color = colorRed
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("Failed to fetch reminder delivery", "id", bm.ID, "error", err)
+		}
+	}
+	if bm.Recurrence != "" {
+		if r, err := storage.ParseRecurrenceRule(bm.Recurrence); err == nil {
+			me.Description += fmt.Sprintf("\n🔁 Repeats %s", r.Description())
+		}
 	}
 	return me
 }
 
-func (b *Bot) fetchUser(userID string) (User, error) {
-	var user User
+// makeOwnerID returns an ID which identifies this bot process among others sharing the database.
+func makeOwnerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "bookmarker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// makeMessageLink returns the link to the bookmarked message.
+func makeMessageLink(bm queries.Bookmark) string {
+	return export.MessageLink(bm.GuildID, bm.ChannelID, bm.MessageID)
+}
+
+func (b *Bot) fetchUser(userID string, options ...discordgo.RequestOption) (User, error) {
+	if user, ok := b.cachedUser(userID); ok {
+		return user, nil
+	}
+	u, err := b.ds.User(userID, options...)
+	if err != nil {
+		return User{}, err
+	}
+	user := User{Name: u.DisplayName(), ID: userID, AvatarURL: u.AvatarURL("")}
+	b.userCache.Store(userID, user)
+	return user, nil
+}
+
+// cachedUser returns a user from the user cache and reports whether it was found.
+func (b *Bot) cachedUser(userID string) (User, bool) {
 	x, ok := b.userCache.Load(userID)
 	if !ok {
-		u, err := b.ds.User(userID)
-		if err != nil {
-			return User{}, err
-		}
-		user = User{Name: u.DisplayName(), ID: userID, AvatarURL: u.AvatarURL("")}
-		b.userCache.Store(userID, user)
-	} else {
-		user = x.(User)
+		return User{}, false
 	}
-	return user, nil
+	return x.(User), true
+}
+
+// channelFailureTTL is how long a failure to fetch a channel is cached.
+// Failures are cached to avoid repeated API calls, e.g. for DMs,
+// but only briefly so that transient errors do not hide channel names until a restart.
+const channelFailureTTL = 5 * time.Minute
+
+// channelCacheEntry is a channel name in the channel cache.
+type channelCacheEntry struct {
+	name      string
+	expiresAt time.Time // zero when the entry never expires
+}
+
+// fetchChannelName returns the name of a channel
+// or an empty string when the channel is not accessible to the bot, e.g. DMs.
+func (b *Bot) fetchChannelName(channelID string, options ...discordgo.RequestOption) string {
+	if name, ok := b.cachedChannelName(channelID); ok {
+		return name
+	}
+	c, err := b.ds.State.Channel(channelID)
+	if err != nil {
+		c, err = b.ds.Channel(channelID, options...)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "" // the caller gave up, which says nothing about the channel
+	} else if err != nil {
+		slog.Debug("Failed to fetch channel", "channelID", channelID, "error", err)
+		b.channelCache.Store(channelID, channelCacheEntry{expiresAt: time.Now().Add(channelFailureTTL)})
+		return ""
+	}
+	b.channelCache.Store(channelID, channelCacheEntry{name: c.Name})
+	return c.Name
+}
+
+// cachedChannelName returns the name of a channel from the channel cache and reports whether it was found.
+// The name is empty when fetching the channel failed.
+func (b *Bot) cachedChannelName(channelID string) (string, bool) {
+	x, ok := b.channelCache.Load(channelID)
+	if !ok {
+		return "", false
+	}
+	e := x.(channelCacheEntry)
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		return "", false
+	}
+	return e.name, true
 }
@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/queries"
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestMakeBookmarkLabel(t *testing.T) {
+	newBot := func(t *testing.T, rt roundTripFunc) *Bot {
+		ds, err := discordgo.New("Bot test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ds.Client = &http.Client{Transport: rt}
+		return &Bot{ds: ds}
+	}
+	makeBookmarks := func(n int) []queries.Bookmark {
+		bookmarks := make([]queries.Bookmark, 0, n)
+		for i := range n {
+			bookmarks = append(bookmarks, queries.Bookmark{
+				ID:        int64(i + 1),
+				AuthorID:  fmt.Sprintf("author-%d", i),
+				ChannelID: fmt.Sprintf("channel-%d", i),
+				Content:   "hello\nworld",
+			})
+		}
+		return bookmarks
+	}
+	t.Run("should show cached names only", func(t *testing.T) {
+		var calls atomic.Int32
+		b := newBot(t, func(r *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return nil, fmt.Errorf("unexpected request: %s", r.URL)
+		})
+		b.userCache.Store("author-0", User{ID: "author-0", Name: "Alice"})
+		b.channelCache.Store("channel-0", channelCacheEntry{name: "general"})
+		bookmarks := makeBookmarks(2)
+		assert.Equal(t, "#1 · Alice in #general: hello world", b.makeBookmarkLabel(bookmarks[0]))
+		assert.Equal(t, "#2: hello world", b.makeBookmarkLabel(bookmarks[1]))
+		assert.Zero(t, calls.Load())
+	})
+	t.Run("should fetch names concurrently", func(t *testing.T) {
+		b := newBot(t, func(r *http.Request) (*http.Response, error) {
+			time.Sleep(200 * time.Millisecond)
+			id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			var body string
+			if strings.Contains(r.URL.Path, "/users/") {
+				body = fmt.Sprintf(`{"id": %q, "username": "user-%s"}`, id, id)
+			} else {
+				body = fmt.Sprintf(`{"id": %q, "name": "name-%s"}`, id, id)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    r,
+			}, nil
+		})
+		bookmarks := makeBookmarks(maxAutocompleteChoices)
+		for i := range bookmarks {
+			bookmarks[i].AuthorID = "author-0" // discordgo sends requests for users one by one
+		}
+		start := time.Now()
+		b.fetchLabelNames(context.Background(), bookmarks)
+		assert.Less(t, time.Since(start), autocompleteFetchTimeout)
+		for _, bm := range bookmarks {
+			want := fmt.Sprintf("#%d · user-author-0 in #name-%s: hello world", bm.ID, bm.ChannelID)
+			assert.Equal(t, want, b.makeBookmarkLabel(bm))
+		}
+	})
+	t.Run("should stop waiting for names when the context is done", func(t *testing.T) {
+		b := newBot(t, func(r *http.Request) (*http.Response, error) {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		})
+		bookmarks := makeBookmarks(3)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		b.fetchLabelNames(ctx, bookmarks)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, "#1: hello world", b.makeBookmarkLabel(bookmarks[0]))
+		_, ok := b.cachedChannelName("channel-0")
+		assert.False(t, ok, "should not cache a canceled fetch as failure")
+	})
+}
+
+func TestBookmarkSavedResponses(t *testing.T) {
+	// newBot returns a bot which records the custom IDs of the buttons
+	// of the last interaction response sent to the API
+	newBot := func(t *testing.T) (*Bot, *[]string) {
+		ds, err := discordgo.New("Bot test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		ds.Client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			var resp struct {
+				Data struct {
+					Components []struct {
+						Components []struct {
+							CustomID string `json:"custom_id"`
+						} `json:"components"`
+					} `json:"components"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+				return nil, err
+			}
+			ids = nil
+			for _, row := range resp.Data.Components {
+				for _, c := range row.Components {
+					ids = append(ids, c.CustomID)
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       io.NopCloser(strings.NewReader("")),
+				Request:    r,
+			}, nil
+		})}
+		return &Bot{owner: "test", st: newTestStorage(t), ds: ds}, &ids
+	}
+	message := &discordgo.Message{
+		Author:    &discordgo.User{ID: "author"},
+		ChannelID: "channel",
+		Content:   "hello",
+		Timestamp: time.Now().UTC(),
+	}
+	t.Run("should offer editing tags and note after creating a bookmark", func(t *testing.T) {
+		b, ids := newBot(t)
+		i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionApplicationCommand,
+			User: &discordgo.User{ID: "user"},
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name:     cmdCreateBookmark,
+				TargetID: "message",
+				Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+					Messages: map[string]*discordgo.Message{"message": message},
+				},
+			},
+		}}
+		if assert.NoError(t, b.handleApplicationCommand(i)) {
+			bookmarks, err := b.st.ListBookmarksForUser("user")
+			if assert.NoError(t, err) && assert.Len(t, bookmarks, 1) {
+				assert.Contains(t, *ids, fmt.Sprintf("%s%d", idEditTags, bookmarks[0].ID))
+				assert.Contains(t, *ids, fmt.Sprintf("%s%d", idEditNote, bookmarks[0].ID))
+			}
+		}
+	})
+	t.Run("should offer editing tags and note after bookmarking with a reminder", func(t *testing.T) {
+		b, _ := newBot(t)
+		for _, dueAt := range []time.Time{{}, time.Now().Add(time.Hour)} {
+			pendingID, err := b.st.CreatePendingBookmark(storage.UpdateOrCreateBookmarkParams{
+				AuthorID:  message.Author.ID,
+				ChannelID: message.ChannelID,
+				Content:   message.Content,
+				MessageID: "message",
+				Timestamp: message.Timestamp,
+				UserID:    "user",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd, err := b.createBookmarkWithReminder(pendingID, "user", dueAt)
+			if assert.NoError(t, err) {
+				bookmarks, err := b.st.ListBookmarksForUser("user")
+				if assert.NoError(t, err) && assert.Len(t, bookmarks, 1) {
+					assert.Contains(t, buttonIDs(rd.Components), fmt.Sprintf("%s%d", idEditTags, bookmarks[0].ID))
+					assert.Contains(t, buttonIDs(rd.Components), fmt.Sprintf("%s%d", idEditNote, bookmarks[0].ID))
+				}
+			}
+		}
+	})
+	t.Run("should offer editing the note again after saving it", func(t *testing.T) {
+		b, _ := newBot(t)
+		id, _, err := b.st.UpdateOrCreateBookmark(storage.UpdateOrCreateBookmarkParams{
+			AuthorID:  message.Author.ID,
+			ChannelID: message.ChannelID,
+			MessageID: "message",
+			Timestamp: message.Timestamp,
+			UserID:    "user",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rd, err := b.setNote(id, "user", "read later")
+		if assert.NoError(t, err) {
+			assert.Equal(t, fmt.Sprintf("Note saved for bookmark #%d", id), rd.Content)
+			assert.Contains(t, buttonIDs(rd.Components), fmt.Sprintf("%s%d", idEditNote, id))
+		}
+	})
+}
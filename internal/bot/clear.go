@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// Discord command names for removing many bookmarks at once
+const (
+	// Clear base command
+	cmdClear = "clear"
+	// Remove all bookmarks
+	cmdClearAll = "all"
+	// Remove bookmarks saved more than a number of days ago
+	cmdClearOlderThan = "older-than"
+	// Remove bookmarks without a reminder
+	cmdClearWithoutReminder = "without-reminder"
+)
+
+// Discord custom IDs for removing many bookmarks at once
+const (
+	idClear          = "clear:"           // followed by the clear subcommand and the days, e.g. "clear:older-than:30"
+	idListRemove     = "list-remove"      // select menu of the bookmark list
+	idRemoveSelected = "remove-selected:" // followed by the bookmark IDs in base 36 separated by dots
+)
+
+// Range of days for the older-than subcommand
+var (
+	minClearDays = 1.0
+	maxClearDays = 3650.0
+)
+
+// commandOptionClear is the clear subcommand group for the bookmarker command.
+var commandOptionClear = &discordgo.ApplicationCommandOption{
+	Description: "Remove many bookmarks at once",
+	Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+	Name:        cmdClear,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Description: "Remove all your bookmarks",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        cmdClearAll,
+		},
+		{
+			Description: "Remove bookmarks saved more than a number of days ago",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        cmdClearOlderThan,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Required:    true,
+					Description: "Number of days, e.g. 30",
+					Name:        "days",
+					MinValue:    &minClearDays,
+					MaxValue:    maxClearDays,
+				},
+			},
+		},
+		{
+			Description: "Remove bookmarks without a reminder",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        cmdClearWithoutReminder,
+		},
+	},
+}
+
+// makeClearMessage returns the message asking the user to confirm removing bookmarks
+// for a clear subcommand.
+func makeClearMessage(cmdOption *discordgo.ApplicationCommandInteractionDataOption) (*discordgo.InteractionResponseData, error) {
+	if len(cmdOption.Options) != 1 {
+		return nil, fmt.Errorf("expected one subcommand only: %+v", cmdOption.Options)
+	}
+	sub := cmdOption.Options[0]
+	var days int64
+	var what string
+	switch sub.Name {
+	case cmdClearAll:
+		what = "**all your bookmarks**"
+	case cmdClearOlderThan:
+		o := findOption(sub.Options, "days")
+		if o == nil {
+			return nil, fmt.Errorf("missing options: %+v", sub.Options)
+		}
+		days = o.IntValue()
+		what = fmt.Sprintf("all bookmarks saved more than **%d days** ago", days)
+	case cmdClearWithoutReminder:
+		what = "all bookmarks **without a reminder**"
+	default:
+		return nil, fmt.Errorf("unexpected clear subcommand: %s", sub.Name)
+	}
+	return makeRemoveConfirmation(
+		fmt.Sprintf("Are you sure you want to remove %s? This can not be undone.", what),
+		fmt.Sprintf("%s%s:%d", idClear, sub.Name, days),
+	), nil
+}
+
+// handleClearButton removes the bookmarks of a clear subcommand after it was confirmed
+// and returns the response message.
+func (b *Bot) handleClearButton(userID string, x string) (string, error) {
+	sub, s, found := strings.Cut(x, ":")
+	if !found {
+		return "", fmt.Errorf("invalid clear ID: %s", x)
+	}
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch sub {
+	case cmdClearAll:
+		n, err = b.st.DeleteAllBookmarksForUser(userID)
+	case cmdClearOlderThan:
+		n, err = b.st.DeleteBookmarksCreatedBeforeForUser(userID, time.Now().AddDate(0, 0, -days))
+	case cmdClearWithoutReminder:
+		n, err = b.st.DeleteBookmarksWithoutReminderForUser(userID)
+	default:
+		return "", fmt.Errorf("unexpected clear subcommand: %s", sub)
+	}
+	if err != nil {
+		return "", err
+	}
+	return makeRemovedContent(n), nil
+}
+
+// makeListRemoveSelect returns a select menu for choosing bookmarks of a list page to remove.
+func (b *Bot) makeListRemoveSelect(bookmarks []queries.Bookmark) discordgo.SelectMenu {
+	bookmarks = bookmarks[:min(len(bookmarks), maxSelectOptions)]
+	b.fetchLabelNames(context.Background(), bookmarks)
+	options := make([]discordgo.SelectMenuOption, 0, len(bookmarks))
+	for _, bm := range bookmarks {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: b.makeBookmarkLabel(bm),
+			Value: strconv.FormatInt(bm.ID, 10),
+		})
+	}
+	minValues := 1
+	return discordgo.SelectMenu{
+		CustomID:    idListRemove,
+		Placeholder: "Select bookmarks to remove",
+		MinValues:   &minValues,
+		MaxValues:   len(options),
+		Options:     options,
+	}
+}
+
+// makeRemoveSelectedMessage returns the message asking the user to confirm
+// removing the bookmarks selected from the bookmark list.
+func makeRemoveSelectedMessage(values []string) (*discordgo.InteractionResponseData, error) {
+	ids := make([]string, 0, len(values))
+	labels := make([]string, 0, len(values))
+	for _, v := range values {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, strconv.FormatInt(id, 36))
+		labels = append(labels, fmt.Sprintf("#%d", id))
+	}
+	customID := idRemoveSelected + strings.Join(ids, ".")
+	if len(customID) > maxCustomIDLength {
+		return &discordgo.InteractionResponseData{
+			Content: "Too many bookmarks selected. Please select fewer bookmarks.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}, nil
+	}
+	return makeRemoveConfirmation(
+		fmt.Sprintf("Are you sure you want to remove these %d bookmarks? %s", len(ids), strings.Join(labels, ", ")),
+		customID,
+	), nil
+}
+
+// handleRemoveSelectedButton removes the bookmarks selected from the bookmark list
+// after it was confirmed and returns the response message.
+func (b *Bot) handleRemoveSelectedButton(userID string, x string) (string, error) {
+	ids := make([]int64, 0)
+	for s := range strings.SplitSeq(x, ".") {
+		id, err := strconv.ParseInt(s, 36, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid remove selected ID: %s: %w", x, err)
+		}
+		ids = append(ids, id)
+	}
+	n, err := b.st.DeleteBookmarksByIDForUser(userID, ids)
+	if err != nil {
+		return "", err
+	}
+	return makeRemovedContent(n), nil
+}
+
+// makeRemoveConfirmation returns a message with buttons for confirming or canceling
+// the removal of bookmarks.
+func makeRemoveConfirmation(content string, customID string) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Remove",
+						Style:    discordgo.DangerButton,
+						CustomID: customID,
+					},
+					discordgo.Button{
+						Label:    "Cancel",
+						CustomID: idCancelRemove,
+					},
+				},
+			},
+		},
+	}
+}
+
+// makeRemovedContent returns the response message after n bookmarks were removed.
+func makeRemovedContent(n int) string {
+	switch n {
+	case 0:
+		return "No bookmarks found to remove"
+	case 1:
+		return "1 bookmark removed"
+	}
+	return fmt.Sprintf("%d bookmarks removed", n)
+}
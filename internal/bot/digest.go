@@ -0,0 +1,213 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/queries"
+	"example/discord-bookmarker/internal/storage"
+)
+
+// Names of the settings command options for the digest
+const (
+	optionDigest     = "digest"
+	optionDigestDay  = "digest-day"
+	optionDigestHour = "digest-hour"
+)
+
+// settingsOptionsDigest are the options of the settings command for the digest.
+var settingsOptionsDigest = []*discordgo.ApplicationCommandOption{
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Description: "Receive reminders one by one or as a daily or weekly digest",
+		Name:        optionDigest,
+		Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{Name: "Off", Value: "off"},
+			{Name: "Daily", Value: storage.DigestDaily},
+			{Name: "Weekly", Value: storage.DigestWeekly},
+		},
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Description: "Hour of day in your timezone when the digest is sent, e.g. 8",
+		Name:        optionDigestHour,
+		MinValue:    new(float64),
+		MaxValue:    23,
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Description: "Day of the week when the weekly digest is sent",
+		Name:        optionDigestDay,
+		Choices:     makeWeekdayChoices(),
+	},
+}
+
+func makeWeekdayChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, 7)
+	for i := range 7 {
+		d := time.Weekday((i + 1) % 7) // starting with Monday
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: d.String(), Value: int(d)})
+	}
+	return choices
+}
+
+// updateDigestFromOptions updates the digest settings of a user from the options of the settings command.
+func updateDigestFromOptions(us *storage.UserSettings, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	if o := findOption(options, optionDigest); o != nil {
+		if v := o.StringValue(); v == "off" {
+			us.DigestMode = storage.DigestOff
+		} else {
+			us.DigestMode = v
+		}
+	}
+	if o := findOption(options, optionDigestHour); o != nil {
+		us.DigestHour = int(o.IntValue())
+	}
+	if o := findOption(options, optionDigestDay); o != nil {
+		us.DigestWeekday = time.Weekday(o.IntValue())
+	}
+}
+
+// formatDigest returns a description of the digest settings, e.g. "Weekly on Monday at 08:00".
+func formatDigest(us storage.UserSettings) string {
+	switch us.DigestMode {
+	case storage.DigestDaily:
+		return fmt.Sprintf("Daily at %02d:00", us.DigestHour)
+	case storage.DigestWeekly:
+		return fmt.Sprintf("Weekly on %s at %02d:00", us.DigestWeekday, us.DigestHour)
+	}
+	return "Off"
+}
+
+// digestKey returns the key of a user in the digest scheduler.
+// Discord IDs are numeric, so they can be used directly.
+func digestKey(userID string) (int64, error) {
+	return strconv.ParseInt(userID, 10, 64)
+}
+
+// scheduleDigest schedules the next digest of a user or removes it when the digest is off.
+// Reminders held for the digest are released when the digest is off.
+func (b *Bot) scheduleDigest(us storage.UserSettings) error {
+	key, err := digestKey(us.UserID)
+	if err != nil {
+		return err
+	}
+	if us.HasDigest() {
+		b.digestSched.Set(key, us.NextDigest(time.Now()))
+		return nil
+	}
+	b.digestSched.Remove(key)
+	_, err = b.st.ReleaseHeldReminders(us.UserID)
+	return err
+}
+
+// updateUserSettings stores the settings of a user and updates the schedule for the digest.
+func (b *Bot) updateUserSettings(us storage.UserSettings) error {
+	if err := b.st.UpdateOrCreateUserSettings(us); err != nil {
+		return err
+	}
+	return b.scheduleDigest(us)
+}
+
+// sendDigests sends the digests which are due. It is called by the digest scheduler.
+func (b *Bot) sendDigests(keys []int64, now time.Time) {
+	for _, key := range keys {
+		userID := strconv.FormatInt(key, 10)
+		us, err := b.st.GetUserSettings(userID)
+		if err != nil {
+			slog.Error("Failed to fetch user settings", "user", userID, "error", err)
+			continue
+		}
+		if !us.HasDigest() {
+			continue
+		}
+		b.sendDigest(us, now)
+		b.digestSched.Set(key, us.NextDigest(now))
+	}
+}
+
+// sendDigest sends the digest to a user. It lists the reminders held for the digest
+// and the reminders which become due until the next digest.
+// The digest is split into several messages when it has more embeds than Discord allows.
+func (b *Bot) sendDigest(us storage.UserSettings, now time.Time) {
+	held, err := b.st.ListHeldBookmarksForUser(us.UserID)
+	if err != nil {
+		slog.Error("Failed to fetch held bookmarks", "user", us.UserID, "error", err)
+		return
+	}
+	next := us.NextDigest(now)
+	upcoming, err := b.st.ListUpcomingBookmarksForUser(us.UserID, next)
+	if err != nil {
+		slog.Error("Failed to fetch upcoming bookmarks", "user", us.UserID, "error", err)
+		return
+	}
+	if len(held) == 0 && len(upcoming) == 0 {
+		return
+	}
+	type entry struct {
+		bm     queries.Bookmark
+		isHeld bool
+	}
+	entries := make([]entry, 0, len(held)+len(upcoming))
+	for _, bm := range held {
+		entries = append(entries, entry{bm: bm, isHeld: true})
+	}
+	for _, bm := range upcoming {
+		entries = append(entries, entry{bm: bm})
+	}
+	var period string
+	if us.DigestMode == storage.DigestWeekly {
+		period = "weekly"
+	} else {
+		period = "daily"
+	}
+	parts := make([]string, 0, 2)
+	if len(held) > 0 {
+		parts = append(parts, fmt.Sprintf("%d reminders due", len(held)))
+	}
+	if len(upcoming) > 0 {
+		parts = append(parts, fmt.Sprintf("%d upcoming until %s", len(upcoming), formatDateTimeForUser(next, us)))
+	}
+	chunks := slices.Collect(slices.Chunk(entries, maxEmbedsPerMessage))
+	for n, chunk := range chunks {
+		var content string
+		if n == 0 {
+			content = fmt.Sprintf("📬 **Your %s digest**: %s", period, strings.Join(parts, ", "))
+		} else {
+			content = fmt.Sprintf("📬 Your %s digest (%d/%d)", period, n+1, len(chunks))
+		}
+		embeds := make([]*discordgo.MessageEmbed, 0, len(chunk))
+		for _, e := range chunk {
+			me := b.makeEmbedFromBookmark(e.bm, makeEmbedFromBookmarkOpts{hideDue: e.isHeld})
+			if e.isHeld {
+				me.Title = "🔔 Due"
+			} else {
+				me.Title = "🗓️ Upcoming"
+			}
+			embeds = append(embeds, me)
+		}
+		if err := b.sendDM(us.UserID, content, embeds, nil); err != nil {
+			slog.Error("Failed to send digest", "user", us.UserID, "error", err)
+			// the held reminders of this and all later messages were not sent
+			// and are retried like single reminders until they fail for good
+			for _, e := range slices.Concat(chunks[n:]...) {
+				if e.isHeld {
+					b.recordDelivery(e.bm, err)
+				}
+			}
+			return
+		}
+		for _, e := range chunk {
+			if e.isHeld {
+				b.recordDelivery(e.bm, nil)
+			}
+		}
+	}
+	slog.Info("Digest sent", "user", us.UserID, "due", len(held), "upcoming", len(upcoming))
+}
@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/scheduler"
+	"example/discord-bookmarker/internal/storage"
+)
+
+// roundTripFunc is an [http.RoundTripper] for faking the Discord API.
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newFailingSession returns a Discord session which fails all API requests with a Discord error code.
+func newFailingSession(t *testing.T, code int) *discordgo.Session {
+	ds, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds.Client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := fmt.Sprintf(`{"message": "failed", "code": %d}`, code)
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}
+	return ds
+}
+
+// newTestStorage returns a storage with a new database in a temporary directory.
+func newTestStorage(t *testing.T) *storage.Storage {
+	dsn := "file:///" + filepath.ToSlash(filepath.Join(t.TempDir(), "test.sqlite"))
+	dbRW, dbRO, err := storage.InitDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		dbRW.Close()
+		dbRO.Close()
+	})
+	return storage.New(dbRW, dbRO)
+}
+
+func TestSendDigest(t *testing.T) {
+	// createHeld creates bookmarks with reminders held for the digest of a user
+	createHeld := func(t *testing.T, st *storage.Storage, userID string, n int) []int64 {
+		ids := make([]int64, 0, n)
+		for i := range n {
+			id, _, err := st.UpdateOrCreateBookmark(storage.UpdateOrCreateBookmarkParams{
+				ChannelID: "channel",
+				DueAt:     time.Now().UTC().Add(-time.Hour),
+				MessageID: fmt.Sprintf("message-%d", i),
+				Timestamp: time.Now().UTC(),
+				UserID:    userID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		hold(t, st, ids)
+		return ids
+	}
+	newBot := func(t *testing.T, code int) (*Bot, storage.UserSettings) {
+		b := &Bot{owner: "test", st: newTestStorage(t), ds: newFailingSession(t, code)}
+		b.sched = scheduler.New(scheduler.RealClock{}, scheduler.CatchUpPolicy{}, b.sendDueReminders)
+		us := storage.DefaultUserSettings("123")
+		us.DigestMode = storage.DigestDaily
+		if err := b.st.UpdateOrCreateUserSettings(us); err != nil {
+			t.Fatal(err)
+		}
+		return b, us
+	}
+	t.Run("should give up on all held reminders when the user does not accept DMs", func(t *testing.T) {
+		b, us := newBot(t, discordgo.ErrCodeCannotSendMessagesToThisUser)
+		ids := createHeld(t, b.st, us.UserID, maxEmbedsPerMessage+2) // two messages
+		b.sendDigest(us, time.Now())
+		for _, id := range ids {
+			_, err := b.st.GetFailedDelivery(id)
+			assert.NoError(t, err, "bookmark %d", id)
+		}
+	})
+	t.Run("should give up on held reminders after too many failed digests", func(t *testing.T) {
+		b, us := newBot(t, discordgo.ErrCodeMissingAccess)
+		ids := createHeld(t, b.st, us.UserID, maxEmbedsPerMessage+2)
+		for n := range storage.MaxDeliveryAttempts {
+			if n > 0 {
+				hold(t, b.st, ids) // the retries are held again for the next digest
+			}
+			b.sendDigest(us, time.Now())
+		}
+		for _, id := range ids {
+			_, err := b.st.GetFailedDelivery(id)
+			assert.NoError(t, err, "bookmark %d", id)
+		}
+	})
+}
+
+func hold(t *testing.T, st *storage.Storage, ids []int64) {
+	for _, id := range ids {
+		bm, err := st.GetBookmark(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.HoldDelivery(id, bm.DueAt.Time); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
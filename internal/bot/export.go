@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/export"
+)
+
+// Discord command names for exports
+const (
+	// Export bookmarks
+	cmdExport = "export"
+)
+
+// commandOptionExport is the export subcommand for the bookmarker command.
+var commandOptionExport = &discordgo.ApplicationCommandOption{
+	Description: "Export your bookmarks as file sent by DM",
+	Type:        discordgo.ApplicationCommandOptionSubCommand,
+	Name:        cmdExport,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+			Description: "File format",
+			Name:        "format",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "JSON", Value: string(export.FormatJSON)},
+				{Name: "CSV", Value: string(export.FormatCSV)},
+				{Name: "Markdown", Value: string(export.FormatMarkdown)},
+				{Name: "HTML", Value: string(export.FormatHTML)},
+			},
+		},
+	},
+}
+
+// handleExportCommand sends the bookmarks of a user as file by DM and returns the response message.
+func (b *Bot) handleExportCommand(userID string, cmdOption *discordgo.ApplicationCommandInteractionDataOption) (string, error) {
+	o := findOption(cmdOption.Options, "format")
+	if o == nil {
+		return "", fmt.Errorf("missing options: %+v", cmdOption.Options)
+	}
+	format, err := export.ParseFormat(o.StringValue())
+	if err != nil {
+		return "", err
+	}
+	bookmarks, err := b.makeExportBookmarks(userID)
+	if err != nil {
+		return "", err
+	}
+	if len(bookmarks) == 0 {
+		return "You have no bookmarks to export", nil
+	}
+	if err := b.sendExport(userID, format, bookmarks); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Export with %d bookmarks sent as DM", len(bookmarks)), nil
+}
+
+// makeExportBookmarks returns all bookmarks of a user for exporting them.
+func (b *Bot) makeExportBookmarks(userID string) ([]export.Bookmark, error) {
+	bookmarks, err := b.st.ListBookmarksForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]export.Bookmark, 0, len(bookmarks))
+	for _, bm := range bookmarks {
+		tags, err := b.st.ListTagsForBookmark(bm.ID)
+		if err != nil {
+			return nil, err
+		}
+		x := export.NewBookmark(bm, tags)
+		if u, err := b.fetchUser(bm.AuthorID); err != nil {
+			slog.Warn("Failed to fetch user", "userID", bm.AuthorID, "error", err)
+		} else {
+			x.AuthorName = u.Name
+		}
+		r = append(r, x)
+	}
+	return r, nil
+}
+
+// sendExport sends bookmarks to a user as file attached to a DM.
+func (b *Bot) sendExport(userID string, format export.Format, bookmarks []export.Bookmark) error {
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, bookmarks); err != nil {
+		return err
+	}
+	c, err := b.ds.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+	_, err = b.ds.ChannelMessageSendComplex(c.ID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("Here is the export of your %d bookmarks.", len(bookmarks)),
+		Files: []*discordgo.File{{
+			Name:        "bookmarks." + format.Extension(),
+			ContentType: format.ContentType(),
+			Reader:      &buf,
+		}},
+	})
+	return err
+}
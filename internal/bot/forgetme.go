@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/export"
+)
+
+// Discord command names for deleting user data
+const (
+	// Delete all data of a user
+	cmdForgetMe = "forget-me"
+)
+
+// Discord custom IDs for deleting user data
+const (
+	idCancelForgetMe = "cancel-forget-me"
+	idForgetMe       = "forget-me:" // followed by 1 when an export is sent first, else 0
+)
+
+// commandOptionForgetMe is the forget-me subcommand for the bookmarker command.
+var commandOptionForgetMe = &discordgo.ApplicationCommandOption{
+	Description: "Delete all your bookmarks, tags, notes and settings",
+	Type:        discordgo.ApplicationCommandOptionSubCommand,
+	Name:        cmdForgetMe,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Description: "Send me an export of my bookmarks before deleting them",
+			Name:        "export",
+		},
+	},
+}
+
+// makeForgetMeMessage returns the message asking the user to confirm deleting all their data.
+func makeForgetMeMessage(withExport bool) *discordgo.InteractionResponseData {
+	content := "Are you sure you want to delete **all your data**? " +
+		"This removes all your bookmarks with their reminders, tags and notes and your settings. " +
+		"It can not be undone."
+	flag := "0"
+	if withExport {
+		content += "\nYou will receive an export of your bookmarks as DM before they are deleted."
+		flag = "1"
+	}
+	return &discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Delete all my data",
+						Style:    discordgo.DangerButton,
+						CustomID: idForgetMe + flag,
+					},
+					discordgo.Button{
+						Label:    "Cancel",
+						CustomID: idCancelForgetMe,
+					},
+				},
+			},
+		},
+	}
+}
+
+// handleForgetMeButton deletes all data of a user after it was confirmed
+// and updates the confirmation message with the outcome.
+func (b *Bot) handleForgetMeButton(i *discordgo.InteractionCreate, userID string, withExport bool) error {
+	err := b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		return err
+	}
+	content, err := b.forgetUser(userID, withExport)
+	if err != nil {
+		slog.Error("Failed to delete user data", "user", userID, "error", err)
+		content = "Sorry, something went wrong while deleting your data"
+	}
+	_, err = b.ds.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &[]discordgo.MessageComponent{},
+	})
+	return err
+}
+
+// forgetUser deletes all data of a user and returns the response message.
+// When withExport is set the bookmarks are sent to the user first
+// and nothing is deleted when sending them fails.
+func (b *Bot) forgetUser(userID string, withExport bool) (string, error) {
+	if withExport {
+		bookmarks, err := b.makeExportBookmarks(userID)
+		if err != nil {
+			return "", err
+		}
+		if len(bookmarks) > 0 {
+			if err := b.sendExport(userID, export.FormatJSON, bookmarks); err != nil {
+				slog.Warn("Failed to send export before deleting user data", "user", userID, "error", err)
+				return "Sorry, I could not send you the export. Nothing was deleted.", nil
+			}
+		}
+	}
+	n, err := b.st.DeleteUser(userID)
+	if err != nil {
+		return "", err
+	}
+	if key, err := digestKey(userID); err == nil {
+		b.digestSched.Remove(key)
+	}
+	return fmt.Sprintf("All your data has been deleted including %d bookmarks. Goodbye!", n), nil
+}
@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/export"
+	"example/discord-bookmarker/internal/storage"
+)
+
+// Discord command names for imports
+const (
+	// Import bookmarks
+	cmdImport = "import"
+)
+
+// maxImportSize is the max size of an uploaded file for imports in bytes.
+const maxImportSize = 1 << 20
+
+// commandOptionImport is the import subcommand for the bookmarker command.
+var commandOptionImport = &discordgo.ApplicationCommandOption{
+	Description: "Import bookmarks from a JSON file created by export",
+	Type:        discordgo.ApplicationCommandOptionSubCommand,
+	Name:        cmdImport,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionAttachment,
+			Required:    true,
+			Description: "Exported file in JSON format",
+			Name:        "file",
+		},
+	},
+}
+
+// handleImportCommand imports the bookmarks from an uploaded file and returns the response message.
+func (b *Bot) handleImportCommand(userID string, data discordgo.ApplicationCommandInteractionData, cmdOption *discordgo.ApplicationCommandInteractionDataOption) (string, error) {
+	o := findOption(cmdOption.Options, "file")
+	if o == nil || data.Resolved == nil {
+		return "", fmt.Errorf("missing options: %+v", cmdOption.Options)
+	}
+	a, ok := data.Resolved.Attachments[o.Value.(string)]
+	if !ok {
+		return "", fmt.Errorf("attachment not found: %v", o.Value)
+	}
+	if a.Size > maxImportSize {
+		return fmt.Sprintf("The file is too large. The maximum is %d KB.", maxImportSize/1024), nil
+	}
+	bookmarks, err := b.downloadExport(a.URL)
+	if err != nil {
+		return fmt.Sprintf("Failed to read the file: %s", err), nil
+	}
+	args := make([]storage.ImportBookmarkParams, 0, len(bookmarks))
+	for _, bm := range bookmarks {
+		arg := storage.ImportBookmarkParams{
+			UpdateOrCreateBookmarkParams: storage.UpdateOrCreateBookmarkParams{
+				AuthorID:  bm.AuthorID,
+				ChannelID: bm.ChannelID,
+				Content:   bm.Content,
+				GuildID:   bm.GuildID,
+				MessageID: bm.MessageID,
+				Timestamp: bm.Timestamp,
+				UserID:    userID,
+			},
+			Note:       bm.Note,
+			Recurrence: bm.Recurrence,
+			Tags:       bm.Tags,
+		}
+		if bm.DueAt != nil {
+			arg.DueAt = *bm.DueAt
+		}
+		args = append(args, arg)
+	}
+	r, err := b.st.ImportBookmarks(userID, args, maxBookmarksPerUser)
+	if err != nil {
+		return "", err
+	}
+	s := fmt.Sprintf("Import completed: %d created, %d updated, %d skipped", r.Created, r.Updated, r.Skipped)
+	if r.Skipped > 0 {
+		s += fmt.Sprintf(
+			"\nBookmarks are skipped when they are invalid or you would have more than %d bookmarks.",
+			maxBookmarksPerUser,
+		)
+	}
+	return s, nil
+}
+
+// downloadExport downloads an export in JSON format and returns its bookmarks.
+func (b *Bot) downloadExport(url string) ([]export.Bookmark, error) {
+	resp, err := b.ds.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	return export.ReadJSON(io.LimitReader(resp.Body, maxImportSize))
+}
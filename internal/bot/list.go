@@ -0,0 +1,387 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+// Discord custom ID prefix for the buttons of the bookmark list.
+//
+// The full ID has the format "list:<button>:<page>:<filter>",
+// so that pages can be turned without keeping any state in the bot.
+// The button part keeps the IDs unique within a message.
+const idListPage = "list:"
+
+// Buttons of the bookmark list
+const (
+	listButtonFirst    = "f"
+	listButtonLast     = "l"
+	listButtonNext     = "n"
+	listButtonPrevious = "p"
+)
+
+// Names of the sort options for the bookmark list
+var listSortNames = map[string]storage.BookmarkSort{
+	"newest-saved":   storage.SortNewestSaved,
+	"oldest-message": storage.SortOldestMessage,
+	"due-soonest":    storage.SortDueSoonest,
+}
+
+// listFilter is a filter with sort order for the bookmark list.
+type listFilter struct {
+	storage.BookmarkFilter
+	sort storage.BookmarkSort
+}
+
+// encode returns the filter in a compact form for custom IDs, e.g. "t=work,r=1".
+// IDs and times are encoded in base 36 to keep custom IDs short.
+func (f listFilter) encode() (string, error) {
+	parts := make([]string, 0)
+	for _, x := range []struct {
+		key string
+		id  string
+	}{
+		{"a", f.AuthorID},
+		{"c", f.ChannelID},
+		{"g", f.GuildID},
+	} {
+		if x.id == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(x.id, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid ID %s: %w", x.id, err)
+		}
+		parts = append(parts, x.key+"="+strconv.FormatUint(v, 36))
+	}
+	if !f.Since.IsZero() {
+		parts = append(parts, "f="+strconv.FormatInt(f.Since.Unix(), 36))
+	}
+	if f.IsOverdue {
+		parts = append(parts, "o=1")
+	}
+	if f.HasReminder {
+		parts = append(parts, "r=1")
+	}
+	if f.sort != storage.SortDefault {
+		parts = append(parts, "s="+strconv.Itoa(int(f.sort)))
+	}
+	if f.Tag != "" {
+		parts = append(parts, "t="+f.Tag)
+	}
+	if !f.Until.IsZero() {
+		parts = append(parts, "u="+strconv.FormatInt(f.Until.Unix(), 36))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// parseListFilter parses a filter encoded with [listFilter.encode].
+func parseListFilter(s string) (listFilter, error) {
+	var f listFilter
+	if s == "" {
+		return f, nil
+	}
+	wrapErr := func(err error) error {
+		return fmt.Errorf("invalid list filter: %s: %w", s, err)
+	}
+	for part := range strings.SplitSeq(s, ",") {
+		k, v, found := strings.Cut(part, "=")
+		if !found {
+			return listFilter{}, wrapErr(fmt.Errorf("missing value"))
+		}
+		switch k {
+		case "a", "c", "g":
+			x, err := strconv.ParseUint(v, 36, 64)
+			if err != nil {
+				return listFilter{}, wrapErr(err)
+			}
+			id := strconv.FormatUint(x, 10)
+			switch k {
+			case "a":
+				f.AuthorID = id
+			case "c":
+				f.ChannelID = id
+			case "g":
+				f.GuildID = id
+			}
+		case "f", "u":
+			x, err := strconv.ParseInt(v, 36, 64)
+			if err != nil {
+				return listFilter{}, wrapErr(err)
+			}
+			if k == "f" {
+				f.Since = time.Unix(x, 0).UTC()
+			} else {
+				f.Until = time.Unix(x, 0).UTC()
+			}
+		case "o":
+			f.IsOverdue = v == "1"
+		case "r":
+			f.HasReminder = v == "1"
+		case "s":
+			x, err := strconv.Atoi(v)
+			if err != nil {
+				return listFilter{}, wrapErr(err)
+			}
+			f.sort = storage.BookmarkSort(x)
+		case "t":
+			f.Tag = v
+		default:
+			return listFilter{}, wrapErr(fmt.Errorf("unknown key %s", k))
+		}
+	}
+	return f, nil
+}
+
+// describe returns a short description of the active filters and sort order
+// or an empty string when none are active.
+func (f listFilter) describe(us storage.UserSettings) string {
+	parts := make([]string, 0)
+	if f.Tag != "" {
+		parts = append(parts, fmt.Sprintf("tag **%s**", f.Tag))
+	}
+	if f.GuildID != "" {
+		parts = append(parts, "this server")
+	}
+	if f.ChannelID != "" {
+		parts = append(parts, fmt.Sprintf("<#%s>", f.ChannelID))
+	}
+	if f.AuthorID != "" {
+		parts = append(parts, fmt.Sprintf("by <@%s>", f.AuthorID))
+	}
+	if f.IsOverdue {
+		parts = append(parts, "overdue")
+	} else if f.HasReminder {
+		parts = append(parts, "with reminder")
+	}
+	loc := us.Location()
+	if !f.Since.IsZero() {
+		parts = append(parts, "from "+f.Since.In(loc).Format(formatDate))
+	}
+	if !f.Until.IsZero() {
+		// until is the start of the day after the end date
+		parts = append(parts, "to "+f.Until.In(loc).AddDate(0, 0, -1).Format(formatDate))
+	}
+	for name, sort := range listSortNames {
+		if sort == f.sort {
+			parts = append(parts, "sorted by "+strings.ReplaceAll(name, "-", " "))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// parseListDate parses a date from a list option in the location loc.
+func parseListDate(s string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(formatDate, strings.TrimSpace(s), loc)
+}
+
+// makeListFilter returns the filter for the options of a list command.
+// It returns a message for the user when an option is invalid.
+func (b *Bot) makeListFilter(i *discordgo.InteractionCreate, userID string, options []*discordgo.ApplicationCommandInteractionDataOption) (listFilter, string, error) {
+	var f listFilter
+	loc := b.userLocation(userID)
+	for _, o := range options {
+		switch o.Name {
+		case "author":
+			f.AuthorID = fmt.Sprint(o.Value)
+		case "channel":
+			f.ChannelID = fmt.Sprint(o.Value)
+		case "from", "to":
+			d, err := parseListDate(o.StringValue(), loc)
+			if err != nil {
+				return listFilter{}, fmt.Sprintf("Sorry, **%s** is not a valid date. Please use the format YYYY-MM-DD.", o.StringValue()), nil
+			}
+			if o.Name == "from" {
+				f.Since = d
+			} else {
+				f.Until = d.AddDate(0, 0, 1)
+			}
+		case "has-reminder":
+			f.HasReminder = o.BoolValue()
+		case "overdue":
+			f.IsOverdue = o.BoolValue()
+		case "sort":
+			f.sort = listSortNames[o.StringValue()]
+		case "tag":
+			tag, err := storage.NormalizeTag(o.StringValue())
+			if errors.Is(err, storage.ErrInvalidTag) {
+				return listFilter{}, makeInvalidTagMessage(o.StringValue()), nil
+			}
+			f.Tag = tag
+		case "this-server":
+			if !o.BoolValue() {
+				continue
+			}
+			if i.GuildID == "" {
+				return listFilter{}, "Filtering by server only works in servers.", nil
+			}
+			f.GuildID = i.GuildID
+		default:
+			return listFilter{}, "", fmt.Errorf("unexpected list option: %s", o.Name)
+		}
+	}
+	if !f.Since.IsZero() && !f.Until.IsZero() && !f.Since.Before(f.Until) {
+		return listFilter{}, "The date for **from** must not be after **to**.", nil
+	}
+	// ensure the filter fits into the custom IDs of the buttons
+	id, err := makeListPageID(listButtonFirst, maxBookmarksPerUser, f)
+	if err != nil {
+		return listFilter{}, "", err
+	}
+	if len(id) > maxCustomIDLength {
+		return listFilter{}, "Too many filters. Please try again with fewer filters.", nil
+	}
+	return f, "", nil
+}
+
+// makeListPageID returns the custom ID of a button for showing a page of the bookmark list.
+func makeListPageID(button string, page int, f listFilter) (string, error) {
+	x, err := f.encode()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s:%d:%s", idListPage, button, page, x), nil
+}
+
+// parseListPageID parses the custom ID of a button of the bookmark list into the page and filter.
+// Reports whether the custom ID belongs to a button of the bookmark list.
+func parseListPageID(customID string) (page int, f listFilter, found bool) {
+	x, found := strings.CutPrefix(customID, idListPage)
+	if !found {
+		return 0, listFilter{}, false
+	}
+	parts := strings.SplitN(x, ":", 3)
+	if len(parts) != 3 {
+		return 0, listFilter{}, false
+	}
+	page, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, listFilter{}, false
+	}
+	f, err = parseListFilter(parts[2])
+	if err != nil {
+		return 0, listFilter{}, false
+	}
+	return page, f, true
+}
+
+// respondWithListPage responds with a page of a user's bookmarks.
+// When isUpdate is true the message of the interaction is updated, e.g. after a button was clicked.
+func (b *Bot) respondWithListPage(i *discordgo.InteractionCreate, userID string, f listFilter, page int, isUpdate bool) error {
+	// Fetching the authors for the embeds can take a while, so we defer the response
+	var t discordgo.InteractionResponseType
+	if isUpdate {
+		t = discordgo.InteractionResponseDeferredMessageUpdate
+	} else {
+		t = discordgo.InteractionResponseDeferredChannelMessageWithSource
+	}
+	err := b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: t,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	edit, err := b.makeListPage(userID, f, page)
+	if err != nil {
+		// the interaction was already responded to, so the user must be told here
+		slog.Error("Failed to show bookmark list", "user", userID, "error", err)
+		content := "Sorry, something went wrong while listing your bookmarks"
+		edit = &discordgo.WebhookEdit{
+			Content:    &content,
+			Embeds:     &[]*discordgo.MessageEmbed{},
+			Components: &[]discordgo.MessageComponent{},
+		}
+	}
+	_, err = b.ds.InteractionResponseEdit(i.Interaction, edit)
+	return err
+}
+
+// makeListPage returns a message showing a page of a user's bookmarks with buttons for turning pages
+// and a select menu for removing bookmarks.
+// Pages start at 0. Pages after the last page show the last page.
+func (b *Bot) makeListPage(userID string, f listFilter, page int) (*discordgo.WebhookEdit, error) {
+	us := b.fetchUserSettings(userID)
+	pageSize := us.ListPageSize
+	page = max(page, 0)
+	bookmarks, total, err := b.st.ListBookmarksPageForUser(userID, f.BookmarkFilter, f.sort, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	pages := max((total+pageSize-1)/pageSize, 1)
+	if page >= pages {
+		// bookmarks were removed since the list was shown
+		page = pages - 1
+		bookmarks, total, err = b.st.ListBookmarksPageForUser(userID, f.BookmarkFilter, f.sort, page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	filters := f.describe(us)
+	var content string
+	if total == 0 && filters == "" {
+		content = "No bookmarked messages yet"
+	} else if total == 0 {
+		content = "No bookmarked messages found for " + filters
+	} else {
+		content = fmt.Sprintf("%d bookmarked messages", total)
+		if filters != "" {
+			content += " for " + filters
+		}
+		if pages > 1 {
+			content += fmt.Sprintf(" · Page %d of %d", page+1, pages)
+		}
+	}
+	embeds := make([]*discordgo.MessageEmbed, 0)
+	for _, bm := range bookmarks {
+		embeds = append(embeds, b.makeEmbedFromBookmark(bm, makeEmbedFromBookmarkOpts{}))
+	}
+	components := make([]discordgo.MessageComponent, 0)
+	if pages > 1 {
+		isFirst, isLast := page == 0, page == pages-1
+		buttons := make([]discordgo.MessageComponent, 0)
+		for _, x := range []struct {
+			button   string
+			label    string
+			page     int
+			disabled bool
+		}{
+			{listButtonFirst, "⏮ First", 0, isFirst},
+			{listButtonPrevious, "◀ Previous", page - 1, isFirst},
+			{listButtonNext, "Next ▶", page + 1, isLast},
+			{listButtonLast, "Last ⏭", pages - 1, isLast},
+		} {
+			id, err := makeListPageID(x.button, x.page, f)
+			if err != nil {
+				return nil, err
+			}
+			buttons = append(buttons, discordgo.Button{
+				Label:    x.label,
+				Style:    discordgo.SecondaryButton,
+				CustomID: id,
+				Disabled: x.disabled,
+			})
+		}
+		components = append(components, discordgo.ActionsRow{Components: buttons})
+	}
+	if len(bookmarks) > 0 {
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{b.makeListRemoveSelect(bookmarks)},
+		})
+	}
+	return &discordgo.WebhookEdit{
+		Content:    &content,
+		Embeds:     &embeds,
+		Components: &components,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestListPageID(t *testing.T) {
+	since := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	fields := []struct {
+		name string
+		set  func(f *listFilter)
+	}{
+		{"author", func(f *listFilter) { f.AuthorID = "123456789012345678" }},
+		{"channel", func(f *listFilter) { f.ChannelID = "223456789012345678" }},
+		{"guild", func(f *listFilter) { f.GuildID = "323456789012345678" }},
+		{"since", func(f *listFilter) { f.Since = since }},
+		{"overdue", func(f *listFilter) { f.IsOverdue = true }},
+		{"reminder", func(f *listFilter) { f.HasReminder = true }},
+		{"sort", func(f *listFilter) { f.sort = storage.SortDueSoonest }},
+		{"tag", func(f *listFilter) { f.Tag = "work" }},
+		{"until", func(f *listFilter) { f.Until = until }},
+	}
+	t.Run("can round-trip all combinations of filters", func(t *testing.T) {
+		for mask := range 1 << len(fields) {
+			var f listFilter
+			names := make([]string, 0)
+			for i, x := range fields {
+				if mask&(1<<i) != 0 {
+					x.set(&f)
+					names = append(names, x.name)
+				}
+			}
+			name := strings.Join(names, ",")
+			id, err := makeListPageID(listButtonNext, 7, f)
+			if !assert.NoError(t, err, name) {
+				continue
+			}
+			assert.LessOrEqual(t, len(id), maxCustomIDLength, name)
+			page, got, found := parseListPageID(id)
+			if assert.True(t, found, name) {
+				assert.Equal(t, 7, page, name)
+				assert.Equal(t, f, got, name)
+			}
+		}
+	})
+	t.Run("can round-trip all sort orders", func(t *testing.T) {
+		for name, sort := range listSortNames {
+			f := listFilter{sort: sort}
+			id, err := makeListPageID(listButtonFirst, 0, f)
+			if assert.NoError(t, err, name) {
+				_, got, found := parseListPageID(id)
+				if assert.True(t, found, name) {
+					assert.Equal(t, f, got, name)
+				}
+			}
+		}
+	})
+	t.Run("should not encode invalid IDs", func(t *testing.T) {
+		_, err := makeListPageID(listButtonFirst, 0, listFilter{
+			BookmarkFilter: storage.BookmarkFilter{AuthorID: "abc"},
+		})
+		assert.Error(t, err)
+	})
+	t.Run("should reject malformed IDs", func(t *testing.T) {
+		for _, id := range []string{
+			"",
+			"remove-bookmark12",
+			"list:",
+			"list:f",
+			"list:f:1",
+			"list:f:x:",
+			"list:f:1:a",
+			"list:f:1:a=",
+			"list:f:1:a=!",
+			"list:f:1:f=?",
+			"list:f:1:u=1.5",
+			"list:f:1:s=x",
+			"list:f:1:x=1",
+			"list:f:1:t=work,",
+		} {
+			_, _, found := parseListPageID(id)
+			assert.False(t, found, fmt.Sprintf("%q", id))
+		}
+	})
+}
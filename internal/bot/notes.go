@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+// Discord custom IDs for notes. They are followed by the bookmark ID.
+const (
+	idEditNote  = "edit-note-"
+	idNoteInput = "note-input"
+	idNoteModal = "note:"
+)
+
+// makeEditNoteButton returns a button for editing the note of a bookmark.
+func makeEditNoteButton(bookmarkID int64) discordgo.Button {
+	return discordgo.Button{
+		Label:    "Add note",
+		Style:    discordgo.SecondaryButton,
+		CustomID: fmt.Sprintf("%s%d", idEditNote, bookmarkID),
+		Emoji:    &discordgo.ComponentEmoji{Name: "📝"},
+	}
+}
+
+// respondWithNoteModal responds with a modal for editing the note of a bookmark.
+func (b *Bot) respondWithNoteModal(i *discordgo.InteractionCreate, userID string, bookmarkID int64) error {
+	bm, err := b.st.GetBookmarkForUser(bookmarkID, userID)
+	if err != nil {
+		return err
+	}
+	return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("%s%d", idNoteModal, bookmarkID),
+			Title:    fmt.Sprintf("Note for bookmark #%d", bookmarkID),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    idNoteInput,
+							Label:       "Why did you save this message?",
+							Style:       discordgo.TextInputParagraph,
+							Placeholder: "Leave empty to remove the note",
+							Value:       bm.Note.String,
+							MaxLength:   storage.MaxNoteLength,
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// setNote sets the note of a bookmark and returns the response.
+// The note is removed when it is empty.
+func (b *Bot) setNote(id int64, userID string, note string) (*discordgo.InteractionResponseData, error) {
+	err := b.st.SetNote(id, userID, note)
+	if errors.Is(err, sql.ErrNoRows) {
+		return makeUpdateData(fmt.Sprintf("No bookmark found with ID #%d", id)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	bm, err := b.st.GetBookmarkForUser(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	var content string
+	if bm.Note.Valid {
+		content = fmt.Sprintf("Note saved for bookmark #%d", id)
+	} else {
+		content = fmt.Sprintf("Note removed from bookmark #%d", id)
+	}
+	return &discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			b.makeEmbedFromBookmark(bm, makeEmbedFromBookmarkOpts{}),
+		},
+		Components: []discordgo.MessageComponent{
+			makeEditBookmarkButtons(id),
+		},
+	}, nil
+}
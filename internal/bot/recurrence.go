@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+// Discord custom IDs for recurring reminders
+const (
+	idCustomRecurrence = "custom-recurrence:"
+	idRecurrenceRule   = "recurrence-rule"
+	idSetRecurrence    = "set-recurrence"
+)
+
+// Values of the recurrence select options without a rule
+const (
+	recurrenceCustomValue = "custom"
+	recurrenceNoneValue   = "none"
+)
+
+// makeReminderSetData returns the data for updating a message after a reminder was set.
+// It includes a select for making the reminder recurring and buttons for editing the bookmark.
+func makeReminderSetData(content string, bookmarkID int64) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    fmt.Sprintf("%s%d", idSetRecurrence, bookmarkID),
+						Placeholder: "Repeat reminder?",
+						Options: []discordgo.SelectMenuOption{
+							{
+								Label:   "Does not repeat",
+								Value:   recurrenceNoneValue,
+								Default: true,
+							},
+							{
+								Label: "Daily",
+								Value: "daily",
+							},
+							{
+								Label: "Every weekday",
+								Value: "weekdays",
+							},
+							{
+								Label: "Weekly",
+								Value: "weekly",
+							},
+							{
+								Label: "Monthly",
+								Value: "monthly",
+							},
+							{
+								Label:       "Custom…",
+								Value:       recurrenceCustomValue,
+								Description: "e.g. weekly:mo,fr or FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+							},
+						},
+					},
+				},
+			},
+			makeEditBookmarkButtons(bookmarkID),
+		},
+	}
+}
+
+// respondWithRecurrenceModal responds with a modal for entering a custom recurrence rule.
+func (b *Bot) respondWithRecurrenceModal(i *discordgo.InteractionCreate, bookmarkID int64) error {
+	return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("%s%d", idCustomRecurrence, bookmarkID),
+			Title:    "Custom recurrence",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    idRecurrenceRule,
+							Label:       "How often should I remind you?",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "e.g. weekly:mo,fr or FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+							Required:    true,
+							MaxLength:   100,
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// setRecurrence sets the recurrence rule for the reminder of a bookmark and returns the response.
+// The recurrence is removed when rule is empty.
+func (b *Bot) setRecurrence(id int64, userID string, rule string) (*discordgo.InteractionResponseData, error) {
+	err := b.st.SetRecurrence(id, userID, rule)
+	if errors.Is(err, sql.ErrNoRows) {
+		return makeUpdateData(fmt.Sprintf("No bookmark found with ID #%d", id)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	bm, err := b.st.GetBookmarkForUser(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	var content string
+	if rule == "" {
+		content = fmt.Sprintf("Reminder for bookmark #%d does not repeat.", id)
+	} else {
+		r, err := storage.ParseRecurrenceRule(bm.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		content = fmt.Sprintf("Reminder for bookmark #%d repeats %s.", id, r.Description())
+	}
+	if bm.DueAt.Valid {
+		content += fmt.Sprintf(" Next reminder %s.", formatDueAt(bm.DueAt.Time, b.fetchUserSettings(userID)))
+	}
+	return makeUpdateData(content), nil
+}
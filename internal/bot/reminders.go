@@ -0,0 +1,346 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/docker/go-units"
+
+	"example/discord-bookmarker/internal/queries"
+	"example/discord-bookmarker/internal/scheduler"
+	"example/discord-bookmarker/internal/storage"
+	"example/discord-bookmarker/internal/timeparse"
+)
+
+// Discord custom IDs for the buttons of reminder DMs. They are followed by the bookmark ID.
+const (
+	idMarkDone       = "mark-done-"
+	idSnooze10Min    = "snooze-10m-"
+	idSnooze1Hour    = "snooze-1h-"
+	idSnoozeTomorrow = "snooze-tomorrow-"
+)
+
+// How long a claimed reminder is reserved for sending it
+const reminderLease = 2 * time.Minute
+
+// How long to wait before trying again when claiming reminders failed, e.g. because the database was busy
+const reminderClaimRetryDelay = 30 * time.Second
+
+// How often to look for due reminders which are not scheduled by this bot
+const reminderSweepInterval = 5 * time.Minute
+
+var reminderButtonIDs = []string{idMarkDone, idSnooze10Min, idSnooze1Hour, idSnoozeTomorrow}
+
+// parseReminderButtonID parses the custom ID of a reminder button into its action and bookmark ID.
+// Reports whether the custom ID belongs to a reminder button.
+func parseReminderButtonID(customID string) (action string, id int64, found bool) {
+	for _, action := range reminderButtonIDs {
+		x, found := strings.CutPrefix(customID, action)
+		if !found {
+			continue
+		}
+		id, err := strconv.ParseInt(x, 10, 64)
+		if err != nil {
+			return "", 0, false
+		}
+		return action, id, true
+	}
+	return "", 0, false
+}
+
+// makeReminderButtons returns the buttons for a reminder DM.
+func makeReminderButtons(bm queries.Bookmark) []discordgo.MessageComponent {
+	makeID := func(action string) string {
+		return fmt.Sprintf("%s%d", action, bm.ID)
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Snooze 10 min",
+					Style:    discordgo.SecondaryButton,
+					CustomID: makeID(idSnooze10Min),
+				},
+				discordgo.Button{
+					Label:    "Snooze 1 h",
+					Style:    discordgo.SecondaryButton,
+					CustomID: makeID(idSnooze1Hour),
+				},
+				discordgo.Button{
+					Label:    "Snooze until tomorrow",
+					Style:    discordgo.SecondaryButton,
+					CustomID: makeID(idSnoozeTomorrow),
+				},
+				discordgo.Button{
+					Label:    "Mark done",
+					Style:    discordgo.SuccessButton,
+					CustomID: makeID(idMarkDone),
+				},
+				discordgo.Button{
+					Label: "Open",
+					Style: discordgo.LinkButton,
+					URL:   makeMessageLink(bm),
+				},
+			},
+		},
+	}
+}
+
+// handleReminderButton handles a click on a button of a reminder DM and returns the updated message.
+func (b *Bot) handleReminderButton(i *discordgo.InteractionCreate, userID string, action string, id int64) (*discordgo.InteractionResponseData, error) {
+	bm, err := b.st.GetBookmarkForUser(id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return makeUpdateData(fmt.Sprintf("No bookmark found with ID #%d", id)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	us := b.fetchUserSettings(userID)
+	var content string
+	var components []discordgo.MessageComponent
+	if action == idMarkDone {
+		next, err := b.st.MarkReminderDone(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		if next.IsZero() {
+			content = "✅ Done. You can keep the bookmark or remove it."
+		} else {
+			content = fmt.Sprintf("✅ Done. Next reminder %s.", formatDueAt(next, us))
+		}
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Remove bookmark",
+						Style:    discordgo.DangerButton,
+						CustomID: fmt.Sprintf("%s%d", idAskRemoveBookmark, id),
+					},
+					discordgo.Button{
+						Label: "Open",
+						Style: discordgo.LinkButton,
+						URL:   makeMessageLink(bm),
+					},
+				},
+			},
+		}
+	} else {
+		now := time.Now()
+		var until time.Time
+		switch action {
+		case idSnooze10Min:
+			until = now.Add(10 * time.Minute)
+		case idSnooze1Hour:
+			until = now.Add(time.Hour)
+		case idSnoozeTomorrow:
+			until, err = timeparse.Parse("tomorrow", now.In(us.Location()))
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unhandled reminder action %s", action)
+		}
+		if err := b.st.SnoozeReminder(id, userID, until.UTC()); err != nil {
+			return nil, err
+		}
+		content = fmt.Sprintf("💤 Snoozed. Will remind you again %s.", formatDueAt(until, us))
+		// the snoozed reminder can be snoozed again or marked done from the same message
+		components = makeReminderButtons(bm)
+	}
+	rd := &discordgo.InteractionResponseData{
+		Content:    content,
+		Components: components,
+	}
+	if i.Message != nil {
+		rd.Embeds = i.Message.Embeds
+	}
+	return rd, nil
+}
+
+// sendDueReminders sends the due reminders of a batch from the scheduler.
+// Reminders are claimed first, so they are sent only once when several bots share the database.
+// Reminders claimed by another bot are scheduled again by storage for when its lease expires.
+// Missed reminders are sent, combined in digests or dropped according to the catch-up policy.
+// Reminders of users who receive digests are held for their next digest
+// and reminders of users in their quiet hours are deferred until the quiet hours end.
+// Failed attempts are retried by storage with a backoff.
+// When claiming fails, the whole batch is tried again after a short delay.
+func (b *Bot) sendDueReminders(batch scheduler.Batch) {
+	bookmarks, err := b.st.ClaimDueBookmarks(batch.IDs(), b.owner, reminderLease)
+	if err != nil {
+		// the jobs were already removed from the scheduler, so they must be scheduled again
+		retry := batch.Now.Add(reminderClaimRetryDelay)
+		slog.Error("Failed to claim due bookmarks. Will retry", "retry", retry, "error", err)
+		for _, id := range batch.IDs() {
+			b.sched.Set(id, retry)
+		}
+		return
+	}
+	due := make(map[int64]queries.Bookmark)
+	settings := make(map[string]storage.UserSettings)
+	for _, bm := range bookmarks {
+		us, ok := settings[bm.UserID]
+		if !ok {
+			us = b.fetchUserSettings(bm.UserID)
+			settings[bm.UserID] = us
+		}
+		if us.HasDigest() {
+			if err := b.st.HoldDelivery(bm.ID, bm.DueAt.Time); err != nil {
+				slog.Error("Failed to hold reminder for digest", "id", bm.ID, "error", err)
+			}
+			continue
+		}
+		if t := us.DeliveryTime(batch.Now); t.After(batch.Now) {
+			if err := b.st.DeferDelivery(bm.ID, bm.DueAt.Time, t); err != nil {
+				slog.Error("Failed to defer reminder for quiet hours", "id", bm.ID, "error", err)
+			}
+			continue
+		}
+		due[bm.ID] = bm
+	}
+	// pick returns the bookmarks for jobs which are still due and claimed by this bot
+	pick := func(jobs []scheduler.Job) []queries.Bookmark {
+		bb := make([]queries.Bookmark, 0, len(jobs))
+		for _, j := range jobs {
+			if bm, ok := due[j.ID]; ok {
+				bb = append(bb, bm)
+			}
+		}
+		return bb
+	}
+	for _, bm := range pick(batch.Send) {
+		b.sendReminder(bm, batch.Now)
+	}
+	digests := make(map[string][]queries.Bookmark)
+	for _, bm := range pick(batch.Digest) {
+		digests[bm.UserID] = append(digests[bm.UserID], bm)
+	}
+	for _, bb := range digests {
+		if len(bb) == 1 {
+			b.sendReminder(bb[0], batch.Now)
+			continue
+		}
+		b.sendReminderDigest(bb, batch.Now)
+	}
+	for _, bm := range pick(batch.Drop) {
+		if _, err := b.st.RecordDeliveryDropped(bm.ID, bm.DueAt.Time); err != nil {
+			slog.Error("Failed to record reminder delivery", "id", bm.ID, "error", err)
+			continue
+		}
+		slog.Info("Missed reminder dropped", "user", bm.UserID, "id", bm.ID, "dueAt", bm.DueAt.Time)
+	}
+}
+
+// sweepDueReminders schedules all due reminders.
+// This is a safety net for due reminders which this bot did not schedule,
+// e.g. reminders set through another bot sharing the database
+// or reminders whose lease expired after the bot holding it crashed.
+func (b *Bot) sweepDueReminders() error {
+	bookmarks, err := b.st.ListDueBookmarks()
+	if err != nil {
+		return err
+	}
+	for _, bm := range bookmarks {
+		b.sched.Set(bm.ID, bm.DueAt.Time)
+	}
+	return nil
+}
+
+// sendReminder sends the reminder for a bookmark and records the attempt.
+func (b *Bot) sendReminder(bm queries.Bookmark, now time.Time) {
+	var author string
+	if u, err := b.fetchUser(bm.AuthorID); err != nil {
+		slog.Warn("Failed to fetch user", "userID", bm.AuthorID, "error", err)
+		author = "someone"
+	} else {
+		author = u.Name
+	}
+	content := fmt.Sprintf("You asked me to remind you about this message from %s:", author)
+	late := b.reminderLateness(bm, now)
+	if !b.catchUp.IsMissed(late) {
+		late = 0
+	} else {
+		content = fmt.Sprintf("Sorry, this reminder is %s late. ", units.HumanDuration(late)) + content
+	}
+	err := b.sendDM(
+		bm.UserID,
+		content,
+		[]*discordgo.MessageEmbed{
+			b.makeEmbedFromBookmark(bm, makeEmbedFromBookmarkOpts{hideDue: true, late: late}),
+		},
+		makeReminderButtons(bm),
+	)
+	b.recordDelivery(bm, err)
+}
+
+// sendReminderDigest sends the missed reminders of a user in one digest and records the attempts.
+// The digest is split into several messages when it has more embeds than Discord allows.
+func (b *Bot) sendReminderDigest(bookmarks []queries.Bookmark, now time.Time) {
+	for chunk := range slices.Chunk(bookmarks, maxEmbedsPerMessage) {
+		embeds := make([]*discordgo.MessageEmbed, 0, len(chunk))
+		for _, bm := range chunk {
+			embeds = append(embeds, b.makeEmbedFromBookmark(bm, makeEmbedFromBookmarkOpts{
+				hideDue: true,
+				late:    b.reminderLateness(bm, now),
+			}))
+		}
+		err := b.sendDM(
+			chunk[0].UserID,
+			fmt.Sprintf("Sorry, I missed %d reminders while I was away. Here they are:", len(bookmarks)),
+			embeds,
+			nil,
+		)
+		for _, bm := range chunk {
+			b.recordDelivery(bm, err)
+		}
+	}
+}
+
+// reminderLateness returns how late the reminder for a bookmark is when sent at now.
+// Delays due to the user's quiet hours do not count.
+func (b *Bot) reminderLateness(bm queries.Bookmark, now time.Time) time.Duration {
+	us := b.fetchUserSettings(bm.UserID)
+	return max(now.Sub(us.DeliveryTime(bm.DueAt.Time)), 0)
+}
+
+// recordDelivery records an attempt to send the reminder for a bookmark.
+// The attempt failed when err is not nil.
+func (b *Bot) recordDelivery(bm queries.Bookmark, err error) {
+	if err != nil {
+		next, err2 := b.st.RecordDeliveryFailure(bm.ID, bm.DueAt.Time, err, isPermanentDMError(err))
+		if err2 != nil {
+			slog.Error("Failed to record reminder delivery", "id", bm.ID, "error", err2)
+			return
+		}
+		if next.IsZero() {
+			slog.Error("Failed to send reminder. Giving up", "user", bm.UserID, "id", bm.ID, "error", err)
+		} else {
+			slog.Warn("Failed to send reminder. Will retry", "user", bm.UserID, "id", bm.ID, "retry", next, "error", err)
+		}
+		return
+	}
+	slog.Info("Reminder sent", "user", bm.UserID, "id", bm.ID)
+	if _, err := b.st.RecordDeliverySuccess(bm.ID, bm.DueAt.Time); err != nil {
+		slog.Error("Failed to record reminder delivery", "id", bm.ID, "error", err)
+	}
+}
+
+// isPermanentDMError reports whether sending a DM failed for a reason which retrying will not fix,
+// e.g. because the user does not accept DMs from the bot.
+func isPermanentDMError(err error) bool {
+	var errREST *discordgo.RESTError
+	if !errors.As(err, &errREST) || errREST.Message == nil {
+		return false
+	}
+	switch errREST.Message.Code {
+	case discordgo.ErrCodeCannotSendMessagesToThisUser, discordgo.ErrCodeUnknownUser:
+		return true
+	}
+	return false
+}
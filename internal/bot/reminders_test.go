@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/scheduler"
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestSendDueReminders(t *testing.T) {
+	t.Run("should schedule reminders again when claiming them fails", func(t *testing.T) {
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Close() // claiming fails with a closed database
+		b := &Bot{owner: "test", st: storage.New(db, db)}
+		b.sched = scheduler.New(scheduler.RealClock{}, scheduler.CatchUpPolicy{}, b.sendDueReminders)
+		now := time.Now()
+		b.sendDueReminders(scheduler.Batch{
+			Now:  now,
+			Send: []scheduler.Job{{ID: 1, DueAt: now}, {ID: 2, DueAt: now.Add(-time.Minute)}},
+		})
+		assert.Equal(t, 2, b.sched.Len())
+		next, ok := b.sched.Next()
+		if assert.True(t, ok) {
+			assert.Equal(t, now.Add(reminderClaimRetryDelay), next)
+		}
+	})
+}
+
+func TestSweepDueReminders(t *testing.T) {
+	dsn := "file:///" + filepath.ToSlash(filepath.Join(t.TempDir(), "test.sqlite"))
+	dbRW, dbRO, err := storage.InitDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		dbRW.Close()
+		dbRO.Close()
+	})
+	// bookmarks created by another bot sharing the database
+	other := storage.New(dbRW, dbRO)
+	dueAt := time.Now().UTC().Add(-time.Minute)
+	for i, d := range []time.Time{dueAt, time.Now().UTC().Add(time.Hour)} {
+		_, _, err := other.UpdateOrCreateBookmark(storage.UpdateOrCreateBookmarkParams{
+			ChannelID: "channel",
+			DueAt:     d,
+			MessageID: fmt.Sprintf("message-%d", i),
+			Timestamp: time.Now().UTC(),
+			UserID:    "user",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := &Bot{owner: "test", st: storage.New(dbRW, dbRO)}
+	b.sched = scheduler.New(scheduler.RealClock{}, scheduler.CatchUpPolicy{}, b.sendDueReminders)
+	if assert.NoError(t, b.sweepDueReminders()) {
+		assert.Equal(t, 1, b.sched.Len())
+		next, ok := b.sched.Next()
+		if assert.True(t, ok) {
+			assert.True(t, dueAt.Equal(next))
+		}
+	}
+}
+
+func TestHandleReminderButton(t *testing.T) {
+	b := &Bot{owner: "test", st: newTestStorage(t), ds: newFailingSession(t, 0)}
+	id, _, err := b.st.UpdateOrCreateBookmark(storage.UpdateOrCreateBookmarkParams{
+		ChannelID: "channel",
+		DueAt:     time.Now().UTC().Add(-time.Minute),
+		MessageID: "message",
+		Timestamp: time.Now().UTC(),
+		UserID:    "user",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{}}
+	t.Run("should keep all reminder buttons after snoozing", func(t *testing.T) {
+		for _, action := range []string{idSnooze10Min, idSnooze1Hour, idSnoozeTomorrow} {
+			rd, err := b.handleReminderButton(i, "user", action, id)
+			if assert.NoError(t, err, action) {
+				assert.Contains(t, rd.Content, "Snoozed", action)
+				assert.Equal(t, []string{
+					fmt.Sprintf("%s%d", idSnooze10Min, id),
+					fmt.Sprintf("%s%d", idSnooze1Hour, id),
+					fmt.Sprintf("%s%d", idSnoozeTomorrow, id),
+					fmt.Sprintf("%s%d", idMarkDone, id),
+				}, buttonIDs(rd.Components), action)
+			}
+		}
+	})
+	t.Run("should ask for confirmation before removing a bookmark marked done", func(t *testing.T) {
+		rd, err := b.handleReminderButton(i, "user", idMarkDone, id)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{fmt.Sprintf("%s%d", idAskRemoveBookmark, id)}, buttonIDs(rd.Components))
+		rd, err = b.makeRemoveBookmarkMessage(id, "user")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{fmt.Sprintf("%s%d", idRemoveBookmark, id), idCancelRemove}, buttonIDs(rd.Components))
+		}
+		_, err = b.st.GetBookmarkForUser(id, "user")
+		assert.NoError(t, err)
+	})
+}
+
+// buttonIDs returns the custom IDs of all buttons in message components.
+// Link buttons have no custom ID and are skipped.
+func buttonIDs(components []discordgo.MessageComponent) []string {
+	ids := make([]string, 0)
+	for _, c := range components {
+		row, ok := c.(discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c := range row.Components {
+			if b, ok := c.(discordgo.Button); ok && b.CustomID != "" {
+				ids = append(ids, b.CustomID)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,331 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/docker/go-units"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+// Discord custom IDs for the settings selects. All start with idSettings.
+const (
+	idSettings           = "settings-"
+	idSettingsLocale     = idSettings + "locale"
+	idSettingsPageSize   = idSettings + "page-size"
+	idSettingsQuietHours = idSettings + "quiet-hours"
+	idSettingsReminder   = idSettings + "reminder"
+	idSettingsTimezone   = idSettings + "timezone"
+)
+
+// reminderDurations are the durations users can choose from when setting a reminder.
+var reminderDurations = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"10 seconds", 10 * time.Second},
+	{"1 hour", time.Hour},
+	{"3 hours", 3 * time.Hour},
+	{"1 day", 24 * time.Hour},
+	{"3 days", 3 * 24 * time.Hour},
+	{"1 week", 7 * 24 * time.Hour},
+}
+
+// timezones are the timezones users can choose from in the settings.
+// Other timezones can be set with the timezone option of the settings command.
+var timezones = []string{
+	"UTC",
+	"Europe/London",
+	"Europe/Lisbon",
+	"Europe/Berlin",
+	"Europe/Paris",
+	"Europe/Athens",
+	"Europe/Moscow",
+	"Africa/Johannesburg",
+	"Asia/Dubai",
+	"Asia/Kolkata",
+	"Asia/Bangkok",
+	"Asia/Shanghai",
+	"Asia/Tokyo",
+	"Australia/Perth",
+	"Australia/Sydney",
+	"Pacific/Auckland",
+	"Pacific/Honolulu",
+	"America/Anchorage",
+	"America/Los_Angeles",
+	"America/Denver",
+	"America/Chicago",
+	"America/New_York",
+	"America/Sao_Paulo",
+	"America/Argentina/Buenos_Aires",
+	"Atlantic/Reykjavik",
+}
+
+// locales are the locales users can choose from with their date time layout.
+var locales = []struct {
+	value  string
+	label  string
+	layout string
+}{
+	{"", "ISO", formatDateTime},
+	{"en-US", "English (US)", "Jan 2, 2006 3:04 PM"},
+	{"en-GB", "English (UK)", "02/01/2006 15:04"},
+	{"de", "German", "02.01.2006 15:04"},
+}
+
+// quietHours are the quiet hours users can choose from as start and end hour.
+var quietHours = [][2]int{
+	{0, 0},
+	{21, 7},
+	{22, 6},
+	{22, 7},
+	{22, 8},
+	{23, 7},
+	{23, 8},
+	{0, 8},
+}
+
+var pageSizes = []int{1, 3, 5, storage.MaxListPageSize}
+
+// formatDateTimeForUser returns t formatted in the timezone and locale of a user.
+func formatDateTimeForUser(t time.Time, us storage.UserSettings) string {
+	layout := formatDateTime
+	for _, l := range locales {
+		if l.value == us.Locale {
+			layout = l.layout
+		}
+	}
+	return t.In(us.Location()).Format(layout + " MST")
+}
+
+// formatDueAt returns a description of when a reminder is due for a user,
+// e.g. "in 3 hours (2025-10-17 17:30 CEST)".
+func formatDueAt(dueAt time.Time, us storage.UserSettings) string {
+	return fmt.Sprintf("in %s (%s)", units.HumanDuration(time.Until(dueAt)), formatDateTimeForUser(dueAt, us))
+}
+
+// formatDeliveryTime returns the time when a reminder due at dueAt is delivered for a user, e.g. "08:00".
+// The date is included when it is on a different day than the due time.
+func formatDeliveryTime(t time.Time, dueAt time.Time, us storage.UserSettings) string {
+	loc := us.Location()
+	t, dueAt = t.In(loc), dueAt.In(loc)
+	if t.YearDay() == dueAt.YearDay() && t.Year() == dueAt.Year() {
+		return t.Format("15:04")
+	}
+	return formatDateTimeForUser(t, us)
+}
+
+func formatQuietHours(start, end int) string {
+	if start == end {
+		return "Off"
+	}
+	return fmt.Sprintf("%02d:00 - %02d:00", start, end)
+}
+
+func formatReminderDuration(d time.Duration) string {
+	if d == 0 {
+		return "None"
+	}
+	for _, x := range reminderDurations {
+		if x.duration == d {
+			return x.label
+		}
+	}
+	return units.HumanDuration(d)
+}
+
+// fetchUserSettings returns the settings of a user.
+// Returns the default settings when they can not be fetched.
+func (b *Bot) fetchUserSettings(userID string) storage.UserSettings {
+	us, err := b.st.GetUserSettings(userID)
+	if err != nil {
+		slog.Error("Failed to fetch user settings", "user", userID, "error", err)
+		return storage.DefaultUserSettings(userID)
+	}
+	return us
+}
+
+// userLocation returns the time zone of a user.
+func (b *Bot) userLocation(userID string) *time.Location {
+	return b.fetchUserSettings(userID).Location()
+}
+
+// makeReminderOptions returns the options for a reminder select.
+// The user's default reminder is pre-selected.
+func (b *Bot) makeReminderOptions(userID string) []discordgo.SelectMenuOption {
+	us := b.fetchUserSettings(userID)
+	options := make([]discordgo.SelectMenuOption, 0)
+	for _, x := range reminderDurations {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:   x.label,
+			Value:   strconv.Itoa(int(x.duration.Seconds())),
+			Default: x.duration == us.DefaultReminder,
+		})
+	}
+	options = append(options, discordgo.SelectMenuOption{
+		Label:       "Custom…",
+		Value:       reminderCustomValue,
+		Description: "e.g. in 45 minutes, tomorrow 9am, next friday 17:30",
+	})
+	options = append(options, discordgo.SelectMenuOption{
+		Label: "Never",
+		Value: "0",
+	})
+	return options
+}
+
+// makeSettingsMessage returns the content of the message for showing and editing the settings of a user.
+func makeSettingsMessage(us storage.UserSettings) *discordgo.InteractionResponseData {
+	now := time.Now()
+	timezoneOptions := make([]discordgo.SelectMenuOption, 0)
+	if !slices.Contains(timezones, us.Timezone) {
+		timezoneOptions = append(timezoneOptions, discordgo.SelectMenuOption{
+			Label:   us.Timezone,
+			Value:   us.Timezone,
+			Default: true,
+		})
+	}
+	for _, tz := range timezones {
+		if len(timezoneOptions) == 25 {
+			break
+		}
+		var description string
+		loc, err := time.LoadLocation(tz)
+		if err == nil {
+			description = now.In(loc).Format("15:04 MST")
+		}
+		timezoneOptions = append(timezoneOptions, discordgo.SelectMenuOption{
+			Label:       tz,
+			Value:       tz,
+			Description: description,
+			Default:     tz == us.Timezone,
+		})
+	}
+	reminderOptions := []discordgo.SelectMenuOption{{
+		Label:   "None",
+		Value:   "0",
+		Default: us.DefaultReminder == 0,
+	}}
+	for _, x := range reminderDurations {
+		reminderOptions = append(reminderOptions, discordgo.SelectMenuOption{
+			Label:   x.label,
+			Value:   strconv.Itoa(int(x.duration.Seconds())),
+			Default: x.duration == us.DefaultReminder,
+		})
+	}
+	pageSizeOptions := make([]discordgo.SelectMenuOption, 0)
+	for _, n := range pageSizes {
+		pageSizeOptions = append(pageSizeOptions, discordgo.SelectMenuOption{
+			Label:   fmt.Sprintf("%d bookmarks per page", n),
+			Value:   strconv.Itoa(n),
+			Default: n == us.ListPageSize,
+		})
+	}
+	quietHoursOptions := make([]discordgo.SelectMenuOption, 0)
+	for _, x := range quietHours {
+		quietHoursOptions = append(quietHoursOptions, discordgo.SelectMenuOption{
+			Label:   "Quiet hours: " + formatQuietHours(x[0], x[1]),
+			Value:   fmt.Sprintf("%d-%d", x[0], x[1]),
+			Default: x[0] == us.QuietHoursStart && x[1] == us.QuietHoursEnd,
+		})
+	}
+	localeOptions := make([]discordgo.SelectMenuOption, 0)
+	for _, l := range locales {
+		localeOptions = append(localeOptions, discordgo.SelectMenuOption{
+			Label:       "Date format: " + l.label,
+			Value:       localeValue(l.value),
+			Description: now.In(us.Location()).Format(l.layout),
+			Default:     l.value == us.Locale,
+		})
+	}
+	makeSelect := func(customID, placeholder string, options []discordgo.SelectMenuOption) discordgo.ActionsRow {
+		return discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    customID,
+					Placeholder: placeholder,
+					Options:     options,
+				},
+			},
+		}
+	}
+	return &discordgo.InteractionResponseData{
+		Content: "Your settings",
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{{
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Timezone", Value: us.Timezone, Inline: true},
+				{Name: "Local time", Value: formatDateTimeForUser(now, us), Inline: true},
+				{Name: "Default reminder", Value: formatReminderDuration(us.DefaultReminder), Inline: true},
+				{Name: "List page size", Value: strconv.Itoa(us.ListPageSize), Inline: true},
+				{Name: "Quiet hours", Value: formatQuietHours(us.QuietHoursStart, us.QuietHoursEnd), Inline: true},
+				{Name: "Digest", Value: formatDigest(us), Inline: true},
+			},
+		}},
+		Components: []discordgo.MessageComponent{
+			makeSelect(idSettingsTimezone, "Choose timezone", timezoneOptions),
+			makeSelect(idSettingsReminder, "Choose default reminder", reminderOptions),
+			makeSelect(idSettingsPageSize, "Choose list page size", pageSizeOptions),
+			makeSelect(idSettingsQuietHours, "Choose quiet hours", quietHoursOptions),
+			makeSelect(idSettingsLocale, "Choose date format", localeOptions),
+		},
+	}
+}
+
+// Discord does not allow empty select values, so the default locale is represented by this value.
+const localeDefaultValue = "default"
+
+func localeValue(locale string) string {
+	if locale == "" {
+		return localeDefaultValue
+	}
+	return locale
+}
+
+// updateSettingsFromSelect updates the settings of a user with the value chosen in a settings select.
+func updateSettingsFromSelect(us *storage.UserSettings, customID, value string) error {
+	switch customID {
+	case idSettingsTimezone:
+		us.Timezone = value
+	case idSettingsReminder:
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		us.DefaultReminder = time.Duration(seconds) * time.Second
+	case idSettingsPageSize:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		us.ListPageSize = n
+	case idSettingsQuietHours:
+		start, end, found := strings.Cut(value, "-")
+		if !found {
+			return fmt.Errorf("invalid quiet hours: %s", value)
+		}
+		var err error
+		us.QuietHoursStart, err = strconv.Atoi(start)
+		if err != nil {
+			return err
+		}
+		us.QuietHoursEnd, err = strconv.Atoi(end)
+		if err != nil {
+			return err
+		}
+	case idSettingsLocale:
+		if value == localeDefaultValue {
+			value = ""
+		}
+		us.Locale = value
+	default:
+		return fmt.Errorf("unhandled settings custom ID %s", customID)
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+// Discord command names for tags
+const (
+	// Tag base command
+	cmdTag = "tag"
+	// Add a tag to a bookmark
+	cmdTagAdd = "add"
+	// List all tags
+	cmdTagList = "list"
+	// Remove a tag from a bookmark
+	cmdTagRemove = "remove"
+)
+
+// Discord custom IDs for tags. They are followed by the bookmark ID.
+const (
+	idEditTags  = "edit-tags-"
+	idTagsInput = "tags-input"
+	idTagsModal = "tags:"
+)
+
+// optionTag is the option for a tag name with autocomplete.
+var optionTag = &discordgo.ApplicationCommandOption{
+	Type:         discordgo.ApplicationCommandOptionString,
+	Required:     true,
+	Description:  "Tag, e.g. work",
+	Name:         "tag",
+	Autocomplete: true,
+	MaxLength:    storage.MaxTagLength,
+}
+
+// commandOptionTag is the tag subcommand group for the bookmarker command.
+var commandOptionTag = &discordgo.ApplicationCommandOption{
+	Description: "Manage tags",
+	Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+	Name:        cmdTag,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Description: "Add a tag to a bookmark",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        cmdTagAdd,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionInteger,
+					Required:     true,
+					Description:  "Bookmark ID",
+					Name:         "bookmark-id",
+					Autocomplete: true,
+				},
+				optionTag,
+			},
+		},
+		{
+			Description: "List your tags",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        cmdTagList,
+		},
+		{
+			Description: "Remove a tag from a bookmark",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        cmdTagRemove,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionInteger,
+					Required:     true,
+					Description:  "Bookmark ID",
+					Name:         "bookmark-id",
+					Autocomplete: true,
+				},
+				optionTag,
+			},
+		},
+	},
+}
+
+// handleTagCommand handles the tag subcommands and returns the response message.
+func (b *Bot) handleTagCommand(userID string, cmdOption *discordgo.ApplicationCommandInteractionDataOption) (string, error) {
+	if len(cmdOption.Options) != 1 {
+		return "", fmt.Errorf("expected one subcommand only: %+v", cmdOption.Options)
+	}
+	sub := cmdOption.Options[0]
+	if sub.Name == cmdTagList {
+		tags, err := b.st.ListTagsForUser(userID)
+		if err != nil {
+			return "", err
+		}
+		if len(tags) == 0 {
+			return "No tags yet", nil
+		}
+		lines := make([]string, 0, len(tags))
+		for _, t := range tags {
+			lines = append(lines, fmt.Sprintf("🏷️ **%s** · %d bookmarks", t.Name, t.Bookmarks))
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+	idOption := findOption(sub.Options, "bookmark-id")
+	tagOption := findOption(sub.Options, "tag")
+	if idOption == nil || tagOption == nil {
+		return "", fmt.Errorf("missing options: %+v", sub.Options)
+	}
+	id := idOption.IntValue()
+	tag, err := storage.NormalizeTag(tagOption.StringValue())
+	if errors.Is(err, storage.ErrInvalidTag) {
+		return makeInvalidTagMessage(tagOption.StringValue()), nil
+	}
+	var content string
+	switch sub.Name {
+	case cmdTagAdd:
+		err = b.st.AddTag(id, userID, tag)
+		content = fmt.Sprintf("Bookmark #%d tagged with **%s**", id, tag)
+	case cmdTagRemove:
+		err = b.st.RemoveTag(id, userID, tag)
+		content = fmt.Sprintf("Tag **%s** removed from bookmark #%d", tag, id)
+	default:
+		return "", fmt.Errorf("unhandled tag subcommand: %s", sub.Name)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		if sub.Name == cmdTagRemove {
+			return fmt.Sprintf("No bookmark found with ID #%d and tag **%s**", id, tag), nil
+		}
+		return fmt.Sprintf("No bookmark found with ID #%d", id), nil
+	} else if errors.Is(err, storage.ErrInvalidTag) {
+		return fmt.Sprintf("Bookmarks can have at most %d tags", storage.MaxTagsPerBookmark), nil
+	} else if err != nil {
+		return "", err
+	}
+	return content, nil
+}
+
+// makeInvalidTagMessage returns the message for an invalid tag name.
+func makeInvalidTagMessage(tag string) string {
+	return fmt.Sprintf(
+		"Sorry, **%s** is not a valid tag. Tags can have up to %d letters, digits, dashes and underscores.",
+		tag,
+		storage.MaxTagLength,
+	)
+}
+
+// respondWithTagChoices responds to an autocomplete interaction with the user's tags matching the input.
+func (b *Bot) respondWithTagChoices(i *discordgo.InteractionCreate, userID string, input string) error {
+	input = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(input), "#"))
+	tags, err := b.st.ListTagsForUser(userID)
+	if err != nil {
+		return err
+	}
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0)
+	for _, t := range tags {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		if !strings.Contains(t.Name, input) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%d)", t.Name, t.Bookmarks),
+			Value: t.Name,
+		})
+	}
+	return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+}
+
+// makeEditTagsButton returns a button for editing the tags of a bookmark.
+func makeEditTagsButton(bookmarkID int64) discordgo.Button {
+	return discordgo.Button{
+		Label:    "Add tags",
+		Style:    discordgo.SecondaryButton,
+		CustomID: fmt.Sprintf("%s%d", idEditTags, bookmarkID),
+		Emoji:    &discordgo.ComponentEmoji{Name: "🏷️"},
+	}
+}
+
+// respondWithTagsModal responds with a modal for editing the tags of a bookmark.
+func (b *Bot) respondWithTagsModal(i *discordgo.InteractionCreate, userID string, bookmarkID int64) error {
+	if _, err := b.st.GetBookmarkForUser(bookmarkID, userID); err != nil {
+		return err
+	}
+	tags, err := b.st.ListTagsForBookmark(bookmarkID)
+	if err != nil {
+		return err
+	}
+	return b.ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("%s%d", idTagsModal, bookmarkID),
+			Title:    fmt.Sprintf("Tags for bookmark #%d", bookmarkID),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    idTagsInput,
+							Label:       "Tags separated by commas",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "e.g. work, read later",
+							Value:       strings.Join(tags, ", "),
+							MaxLength:   (storage.MaxTagLength + 2) * storage.MaxTagsPerBookmark,
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// setTags replaces the tags of a bookmark with the tags from a user input and returns the response.
+func (b *Bot) setTags(id int64, userID string, input string) (*discordgo.InteractionResponseData, error) {
+	tags, err := storage.ParseTags(input)
+	if errors.Is(err, storage.ErrInvalidTag) {
+		return makeUpdateData(makeInvalidTagMessage(input)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(tags) > storage.MaxTagsPerBookmark {
+		return makeUpdateData(fmt.Sprintf("Bookmarks can have at most %d tags", storage.MaxTagsPerBookmark)), nil
+	}
+	err = b.st.SetTags(id, userID, tags)
+	if errors.Is(err, sql.ErrNoRows) {
+		return makeUpdateData(fmt.Sprintf("No bookmark found with ID #%d", id)), nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return makeUpdateData(fmt.Sprintf("Tags removed from bookmark #%d", id)), nil
+	}
+	return makeBookmarkSavedData(fmt.Sprintf("Bookmark #%d tagged with **%s**", id, strings.Join(tags, "**, **")), id), nil
+}
+
+// makeTagsFooter returns the tags of a bookmark for the footer of an embed
+// or an empty string when it has no tags.
+func (b *Bot) makeTagsFooter(bookmarkID int64) string {
+	tags, err := b.st.ListTagsForBookmark(bookmarkID)
+	if err != nil {
+		slog.Warn("Failed to fetch tags", "id", bookmarkID, "error", err)
+		return ""
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return "🏷️ " + strings.Join(tags, ", ")
+}
+
+// findOption returns the option with the given name or nil if not found.
+func findOption(options []*discordgo.ApplicationCommandInteractionDataOption, name string) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, o := range options {
+		if o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// findFocusedOption returns the focused option of an autocomplete interaction or nil if not found.
+func findFocusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, o := range options {
+		if o.Focused {
+			return o
+		}
+		if x := findFocusedOption(o.Options); x != nil {
+			return x
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+// Package export renders bookmarks into files for exporting them.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// Format is a file format for exports.
+type Format string
+
+// Supported formats
+const (
+	FormatCSV      Format = "csv"
+	FormatHTML     Format = "html"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "markdown"
+)
+
+// Formats returns all supported formats.
+func Formats() []Format {
+	return []Format{FormatJSON, FormatCSV, FormatMarkdown, FormatHTML}
+}
+
+// ParseFormat returns the format for a name, e.g. "json".
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats() {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format: %q", s)
+}
+
+// Extension returns the file extension for a format.
+func (f Format) Extension() string {
+	if f == FormatMarkdown {
+		return "md"
+	}
+	return string(f)
+}
+
+// ContentType returns the MIME type for a format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatHTML:
+		return "text/html"
+	case FormatJSON:
+		return "application/json"
+	case FormatMarkdown:
+		return "text/markdown"
+	}
+	return "application/octet-stream"
+}
+
+// Version of the JSON format
+const Version = 1
+
+// Bookmark is a bookmark as exported.
+type Bookmark struct {
+	ID         int64      `json:"id"`
+	AuthorID   string     `json:"author_id"`
+	AuthorName string     `json:"author_name,omitempty"`
+	ChannelID  string     `json:"channel_id"`
+	Content    string     `json:"content"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DueAt      *time.Time `json:"due_at,omitempty"`
+	GuildID    string     `json:"guild_id,omitempty"`
+	Link       string     `json:"link"`
+	MessageID  string     `json:"message_id"`
+	Note       string     `json:"note,omitempty"`
+	Recurrence string     `json:"recurrence,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Timestamp  time.Time  `json:"timestamp"`
+}
+
+// NewBookmark returns a stored bookmark with its tags as exported.
+// The author name is not stored and must be set by the caller.
+func NewBookmark(bm queries.Bookmark, tags []string) Bookmark {
+	x := Bookmark{
+		ID:         bm.ID,
+		AuthorID:   bm.AuthorID,
+		ChannelID:  bm.ChannelID,
+		Content:    bm.Content,
+		CreatedAt:  bm.CreatedAt,
+		GuildID:    bm.GuildID,
+		Link:       MessageLink(bm.GuildID, bm.ChannelID, bm.MessageID),
+		MessageID:  bm.MessageID,
+		Note:       bm.Note.String,
+		Recurrence: bm.Recurrence,
+		Tags:       tags,
+		Timestamp:  bm.Timestamp,
+	}
+	if bm.DueAt.Valid {
+		x.DueAt = &bm.DueAt.Time
+	}
+	return x
+}
+
+// MessageLink returns the URL of a Discord message. The guild ID is empty for DMs.
+func MessageLink(guildID, channelID, messageID string) string {
+	if guildID == "" {
+		guildID = "@me"
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
+// File is the content of an export in JSON format.
+type File struct {
+	Version   int        `json:"version"`
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// Write writes bookmarks to w in a format.
+func Write(w io.Writer, f Format, bookmarks []Bookmark) error {
+	switch f {
+	case FormatCSV:
+		return writeCSV(w, bookmarks)
+	case FormatHTML:
+		return writeHTML(w, bookmarks)
+	case FormatJSON:
+		return writeJSON(w, bookmarks)
+	case FormatMarkdown:
+		return writeMarkdown(w, bookmarks)
+	}
+	return fmt.Errorf("unknown export format: %q", f)
+}
+
+func writeJSON(w io.Writer, bookmarks []Bookmark) error {
+	if bookmarks == nil {
+		bookmarks = []Bookmark{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(File{Version: Version, Bookmarks: bookmarks})
+}
+
+// ReadJSON reads bookmarks from an export in JSON format.
+func ReadJSON(r io.Reader) ([]Bookmark, error) {
+	var f File
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("unsupported version: %d", f.Version)
+	}
+	return f.Bookmarks, nil
+}
+
+var csvHeader = []string{
+	"id",
+	"created_at",
+	"timestamp",
+	"author_id",
+	"author_name",
+	"guild_id",
+	"channel_id",
+	"message_id",
+	"link",
+	"content",
+	"note",
+	"tags",
+	"due_at",
+	"recurrence",
+}
+
+func writeCSV(w io.Writer, bookmarks []Bookmark) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, bm := range bookmarks {
+		err := cw.Write([]string{
+			strconv.FormatInt(bm.ID, 10),
+			formatTime(bm.CreatedAt),
+			formatTime(bm.Timestamp),
+			bm.AuthorID,
+			bm.AuthorName,
+			bm.GuildID,
+			bm.ChannelID,
+			bm.MessageID,
+			bm.Link,
+			bm.Content,
+			bm.Note,
+			strings.Join(bm.Tags, ", "),
+			formatDueAt(bm.DueAt),
+			bm.Recurrence,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeMarkdown(w io.Writer, bookmarks []Bookmark) error {
+	var b strings.Builder
+	b.WriteString("# Bookmarks\n")
+	for _, bm := range bookmarks {
+		fmt.Fprintf(&b, "\n## #%d", bm.ID)
+		if bm.AuthorName != "" {
+			fmt.Fprintf(&b, " · %s", bm.AuthorName)
+		}
+		b.WriteString("\n\n")
+		if bm.Content != "" {
+			for line := range strings.SplitSeq(bm.Content, "\n") {
+				fmt.Fprintf(&b, "> %s\n", line)
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "- Link: %s\n", bm.Link)
+		fmt.Fprintf(&b, "- Sent: %s\n", formatTime(bm.Timestamp))
+		fmt.Fprintf(&b, "- Saved: %s\n", formatTime(bm.CreatedAt))
+		if bm.DueAt != nil {
+			fmt.Fprintf(&b, "- Reminder: %s\n", formatDueAt(bm.DueAt))
+		}
+		if bm.Recurrence != "" {
+			fmt.Fprintf(&b, "- Repeats: %s\n", bm.Recurrence)
+		}
+		if len(bm.Tags) > 0 {
+			fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(bm.Tags, ", "))
+		}
+		if bm.Note != "" {
+			fmt.Fprintf(&b, "- Note: %s\n", strings.ReplaceAll(bm.Note, "\n", " "))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"formatTime":  formatTime,
+	"formatDueAt": formatDueAt,
+	"join":        strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Bookmarks</title>
+</head>
+<body>
+<h1>Bookmarks</h1>
+{{- range .}}
+<article>
+<h2>#{{.ID}}{{if .AuthorName}} · {{.AuthorName}}{{end}}</h2>
+{{- if .Content}}
+<blockquote>{{.Content}}</blockquote>
+{{- end}}
+<ul>
+<li>Link: <a href="{{.Link}}">{{.Link}}</a></li>
+<li>Sent: {{formatTime .Timestamp}}</li>
+<li>Saved: {{formatTime .CreatedAt}}</li>
+{{- if .DueAt}}
+<li>Reminder: {{formatDueAt .DueAt}}</li>
+{{- end}}
+{{- if .Recurrence}}
+<li>Repeats: {{.Recurrence}}</li>
+{{- end}}
+{{- if .Tags}}
+<li>Tags: {{join .Tags ", "}}</li>
+{{- end}}
+{{- if .Note}}
+<li>Note: {{.Note}}</li>
+{{- end}}
+</ul>
+</article>
+{{- end}}
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, bookmarks []Bookmark) error {
+	return htmlTemplate.Execute(w, bookmarks)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatDueAt(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
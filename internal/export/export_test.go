@@ -0,0 +1,148 @@
+package export_test
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/export"
+	"example/discord-bookmarker/internal/queries"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func makeBookmarks() []export.Bookmark {
+	dueAt := time.Date(2025, 10, 20, 8, 0, 0, 0, time.UTC)
+	return []export.Bookmark{
+		{
+			ID:         1,
+			AuthorID:   "100",
+			AuthorName: "Alice",
+			ChannelID:  "200",
+			Content:    "Release is on Friday.\nDon't forget the <changelog> & notes!",
+			CreatedAt:  time.Date(2025, 10, 17, 10, 0, 0, 0, time.UTC),
+			DueAt:      &dueAt,
+			GuildID:    "300",
+			Link:       "https://discord.com/channels/300/200/400",
+			MessageID:  "400",
+			Note:       "Check with \"Bob\", too",
+			Recurrence: "weekly",
+			Tags:       []string{"release", "work"},
+			Timestamp:  time.Date(2025, 10, 17, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			ID:        2,
+			AuthorID:  "101",
+			ChannelID: "201",
+			Content:   "Hi, there",
+			CreatedAt: time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC),
+			Link:      "https://discord.com/channels/@me/201/401",
+			MessageID: "401",
+			Timestamp: time.Date(2025, 10, 18, 11, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	for _, f := range export.Formats() {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := export.Write(&buf, f, makeBookmarks())
+			if !assert.NoError(t, err) {
+				return
+			}
+			golden := filepath.Join("testdata", "bookmarks."+f.Extension()+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, string(want), buf.String())
+		})
+	}
+	t.Run("should reject unknown format", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := export.Write(&buf, "xml", makeBookmarks())
+		assert.Error(t, err)
+	})
+}
+
+func TestReadJSON(t *testing.T) {
+	t.Run("can read what was written", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := export.Write(&buf, export.FormatJSON, makeBookmarks()); err != nil {
+			t.Fatal(err)
+		}
+		got, err := export.ReadJSON(&buf)
+		if assert.NoError(t, err) {
+			assert.Equal(t, makeBookmarks(), got)
+		}
+	})
+	t.Run("should reject unsupported version", func(t *testing.T) {
+		_, err := export.ReadJSON(bytes.NewBufferString(`{"version": 2, "bookmarks": []}`))
+		assert.Error(t, err)
+	})
+	t.Run("should reject invalid JSON", func(t *testing.T) {
+		_, err := export.ReadJSON(bytes.NewBufferString(`[`))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range export.Formats() {
+		got, err := export.ParseFormat(string(f))
+		if assert.NoError(t, err) {
+			assert.Equal(t, f, got)
+		}
+	}
+	_, err := export.ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestNewBookmark(t *testing.T) {
+	dueAt := time.Date(2025, 10, 20, 8, 0, 0, 0, time.UTC)
+	bm := queries.Bookmark{
+		ID:         1,
+		AuthorID:   "100",
+		ChannelID:  "200",
+		Content:    "content",
+		CreatedAt:  time.Date(2025, 10, 17, 10, 0, 0, 0, time.UTC),
+		DueAt:      sql.NullTime{Time: dueAt, Valid: true},
+		GuildID:    "300",
+		MessageID:  "400",
+		Note:       sql.NullString{String: "note", Valid: true},
+		Recurrence: "FREQ=WEEKLY",
+		Timestamp:  time.Date(2025, 10, 17, 9, 30, 0, 0, time.UTC),
+	}
+	got := export.NewBookmark(bm, []string{"work"})
+	assert.Equal(t, export.Bookmark{
+		ID:         1,
+		AuthorID:   "100",
+		ChannelID:  "200",
+		Content:    "content",
+		CreatedAt:  bm.CreatedAt,
+		DueAt:      &dueAt,
+		GuildID:    "300",
+		Link:       "https://discord.com/channels/300/200/400",
+		MessageID:  "400",
+		Note:       "note",
+		Recurrence: "FREQ=WEEKLY",
+		Tags:       []string{"work"},
+		Timestamp:  bm.Timestamp,
+	}, got)
+}
+
+func TestMessageLink(t *testing.T) {
+	assert.Equal(t, "https://discord.com/channels/1/2/3", export.MessageLink("1", "2", "3"))
+	assert.Equal(t, "https://discord.com/channels/@me/2/3", export.MessageLink("", "2", "3"))
+}
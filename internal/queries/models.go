@@ -10,15 +10,76 @@ import (
 )
 
 type Bookmark struct {
+	ID               int64
+	AuthorID         string
+	ChannelID        string
+	Content          string
+	CreatedAt        time.Time
+	DueAt            sql.NullTime
+	GuildID          string
+	MessageID        string
+	Note             sql.NullString
+	Recurrence       string
+	RecurrenceAnchor sql.NullTime
+	Timestamp        time.Time
+	UpdatedAt        time.Time
+	UserID           string
+}
+
+type BookmarkTag struct {
+	BookmarkID int64
+	TagID      int64
+}
+
+type BookmarksFt struct {
+	Content string
+	Note    string
+}
+
+type PendingBookmark struct {
 	ID        int64
 	AuthorID  string
 	ChannelID string
 	Content   string
 	CreatedAt time.Time
-	DueAt     sql.NullTime
+	ExpiresAt time.Time
 	GuildID   string
 	MessageID string
 	Timestamp time.Time
-	UpdatedAt time.Time
 	UserID    string
 }
+
+type ReminderDelivery struct {
+	ID             int64
+	Attempts       int64
+	BookmarkID     int64
+	CreatedAt      time.Time
+	DueAt          time.Time
+	Error          string
+	LeaseExpiresAt sql.NullTime
+	LeaseOwner     string
+	NextAttemptAt  sql.NullTime
+	Status         string
+	UpdatedAt      time.Time
+}
+
+type Tag struct {
+	ID     int64
+	Name   string
+	UserID string
+}
+
+type UserSetting struct {
+	UserID          string
+	CreatedAt       time.Time
+	DefaultReminder int64
+	DigestHour      int64
+	DigestMode      string
+	DigestWeekday   int64
+	ListPageSize    int64
+	Locale          string
+	QuietHoursEnd   int64
+	QuietHoursStart int64
+	Timezone        string
+	UpdatedAt       time.Time
+}
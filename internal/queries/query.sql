@@ -16,6 +16,28 @@ WHERE
 LIMIT
   1;
 
+-- name: GetBookmarkForUser :one
+SELECT
+  *
+FROM
+  bookmarks
+WHERE
+  id = ?
+  AND user_id = ?
+LIMIT
+  1;
+
+-- name: GetBookmarkIDForMessage :one
+SELECT
+  id
+FROM
+  bookmarks
+WHERE
+  channel_id = ?
+  AND guild_id = ?
+  AND message_id = ?
+  AND user_id = ?;
+
 -- name: ListDueBookmarks :many
 SELECT
   *
@@ -23,7 +45,41 @@ FROM
   bookmarks
 WHERE
   due_at IS NOT NULL
-  AND due_at < sqlc.arg(now);
+  AND due_at <= ?1
+  AND NOT EXISTS (
+    SELECT
+      1
+    FROM
+      reminder_deliveries
+    WHERE
+      reminder_deliveries.bookmark_id = bookmarks.id
+      AND reminder_deliveries.due_at = bookmarks.due_at
+      AND (
+        reminder_deliveries.status NOT IN ('deferred', 'pending', 'sending')
+        OR (
+          reminder_deliveries.status IN ('deferred', 'pending')
+          AND reminder_deliveries.next_attempt_at > ?1
+        )
+        OR (
+          reminder_deliveries.status = 'sending'
+          AND reminder_deliveries.lease_expires_at > ?1
+        )
+      )
+  );
+
+-- name: ListReminders :many
+SELECT
+  bookmarks.id,
+  bookmarks.due_at,
+  reminder_deliveries.lease_expires_at,
+  reminder_deliveries.next_attempt_at,
+  reminder_deliveries.status
+FROM
+  bookmarks
+  LEFT JOIN reminder_deliveries ON reminder_deliveries.bookmark_id = bookmarks.id
+  AND reminder_deliveries.due_at = bookmarks.due_at
+WHERE
+  bookmarks.due_at IS NOT NULL;
 
 -- name: ListBookmarksForUser :many
 SELECT
@@ -35,10 +91,27 @@ WHERE
 ORDER BY
   guild_id, channel_id, timestamp;
 
--- name: DeleteBookmark :exec
+-- name: ListBookmarksMatchingForUser :many
+SELECT
+  *
+FROM
+  bookmarks
+WHERE
+  user_id = sqlc.arg(user_id)
+  AND (
+    instr(CAST(id AS TEXT), sqlc.arg(input)) = 1
+    OR instr(lower(content), lower(sqlc.arg(input))) > 0
+  )
+ORDER BY
+  guild_id, channel_id, timestamp
+LIMIT
+  sqlc.arg(limit);
+
+-- name: DeleteBookmark :execrows
 DELETE FROM bookmarks
 WHERE
-  id = ?;
+  id = ?
+  AND user_id = ?;
 
 -- name: DeleteAllBookmarks :exec
 DELETE FROM bookmarks;
@@ -50,7 +123,52 @@ SET
 WHERE
   id = ?;
 
--- name: UpdateOrCreateBookmark :execlastid
+-- name: ClearBookmarkReminder :exec
+Update bookmarks
+SET
+  due_at = NULL,
+  recurrence = '',
+  recurrence_anchor = NULL
+WHERE
+  id = ?;
+
+-- name: ClearBookmarkReminderForUser :execrows
+Update bookmarks
+SET
+  due_at = NULL,
+  recurrence = '',
+  recurrence_anchor = NULL
+WHERE
+  id = ?
+  AND user_id = ?;
+
+-- name: UpdateBookmarkRecurrenceForUser :execrows
+Update bookmarks
+SET
+  recurrence = ?,
+  recurrence_anchor = due_at
+WHERE
+  id = ?
+  AND user_id = ?;
+
+-- name: UpdateBookmarkDueAtForUser :execrows
+Update bookmarks
+SET
+  due_at = ?1,
+  recurrence_anchor = ?1
+WHERE
+  id = ?2
+  AND user_id = ?3;
+
+-- name: SnoozeBookmarkForUser :execrows
+Update bookmarks
+SET
+  due_at = ?
+WHERE
+  id = ?
+  AND user_id = ?;
+
+-- name: UpdateOrCreateBookmark :exec
 INSERT INTO
   bookmarks (
     author_id,
@@ -70,4 +188,359 @@ ON CONFLICT (channel_id, guild_id, message_id, user_id) DO UPDATE
 SET
   created_at = ?4,
   due_at = ?5,
+  recurrence = CASE
+    WHEN ?5 IS NULL THEN ''
+    ELSE recurrence
+  END,
+  recurrence_anchor = ?5,
   updated_at = ?9;
+
+
+-- name: GetUserSettings :one
+SELECT
+  *
+FROM
+  user_settings
+WHERE
+  user_id = ?;
+
+-- name: DeleteUserSettings :exec
+DELETE FROM user_settings
+WHERE
+  user_id = ?;
+
+-- name: UpdateOrCreateUserSettings :exec
+INSERT INTO
+  user_settings (
+    user_id,
+    created_at,
+    default_reminder,
+    digest_hour,
+    digest_mode,
+    digest_weekday,
+    list_page_size,
+    locale,
+    quiet_hours_end,
+    quiet_hours_start,
+    timezone,
+    updated_at
+  )
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE
+SET
+  default_reminder = ?3,
+  digest_hour = ?4,
+  digest_mode = ?5,
+  digest_weekday = ?6,
+  list_page_size = ?7,
+  locale = ?8,
+  quiet_hours_end = ?9,
+  quiet_hours_start = ?10,
+  timezone = ?11,
+  updated_at = ?12;
+
+-- name: ListDigestUserSettings :many
+SELECT
+  *
+FROM
+  user_settings
+WHERE
+  digest_mode != '';
+
+-- name: CreatePendingBookmark :execlastid
+INSERT INTO
+  pending_bookmarks (
+    author_id,
+    channel_id,
+    content,
+    created_at,
+    expires_at,
+    guild_id,
+    message_id,
+    timestamp,
+    user_id
+  )
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetPendingBookmark :one
+SELECT
+  *
+FROM
+  pending_bookmarks
+WHERE
+  id = ?
+  AND user_id = ?
+  AND expires_at > sqlc.arg(now);
+
+-- name: DeletePendingBookmark :exec
+DELETE FROM pending_bookmarks
+WHERE
+  id = ?;
+
+-- name: DeleteExpiredPendingBookmarks :execrows
+DELETE FROM pending_bookmarks
+WHERE
+  expires_at <= sqlc.arg(now);
+
+-- name: CreateTag :exec
+INSERT INTO
+  tags (name, user_id)
+VALUES
+  (?, ?)
+ON CONFLICT (user_id, name) DO NOTHING;
+
+-- name: GetTagID :one
+SELECT
+  id
+FROM
+  tags
+WHERE
+  user_id = ?
+  AND name = ?;
+
+-- name: AddBookmarkTag :exec
+INSERT INTO
+  bookmark_tags (bookmark_id, tag_id)
+VALUES
+  (?, ?)
+ON CONFLICT (bookmark_id, tag_id) DO NOTHING;
+
+-- name: DeleteBookmarkTag :execrows
+DELETE FROM bookmark_tags
+WHERE
+  bookmark_id = ?
+  AND tag_id IN (
+    SELECT
+      id
+    FROM
+      tags
+    WHERE
+      user_id = ?
+      AND name = ?
+  );
+
+-- name: DeleteBookmarkTags :exec
+DELETE FROM bookmark_tags
+WHERE
+  bookmark_id = ?;
+
+-- name: DeleteUnusedTags :exec
+DELETE FROM tags
+WHERE
+  user_id = ?
+  AND id NOT IN (
+    SELECT
+      tag_id
+    FROM
+      bookmark_tags
+  );
+
+-- name: ListTagsForBookmark :many
+SELECT
+  tags.name
+FROM
+  tags
+  JOIN bookmark_tags ON bookmark_tags.tag_id = tags.id
+WHERE
+  bookmark_tags.bookmark_id = ?
+ORDER BY
+  tags.name;
+
+-- name: ListTagsForUser :many
+SELECT
+  tags.name,
+  COUNT(bookmark_tags.bookmark_id) AS bookmarks
+FROM
+  tags
+  JOIN bookmark_tags ON bookmark_tags.tag_id = tags.id
+WHERE
+  tags.user_id = ?
+GROUP BY
+  tags.id
+ORDER BY
+  tags.name;
+
+-- name: UpdateBookmarkNoteForUser :execrows
+Update bookmarks
+SET
+  note = ?,
+  updated_at = ?
+WHERE
+  id = ?
+  AND user_id = ?;
+
+-- name: GetReminderDelivery :one
+SELECT
+  *
+FROM
+  reminder_deliveries
+WHERE
+  bookmark_id = ?
+  AND due_at = ?;
+
+-- name: GetFailedReminderDelivery :one
+SELECT
+  reminder_deliveries.*
+FROM
+  reminder_deliveries
+  JOIN bookmarks ON bookmarks.id = reminder_deliveries.bookmark_id
+  AND bookmarks.due_at = reminder_deliveries.due_at
+WHERE
+  reminder_deliveries.bookmark_id = ?
+  AND reminder_deliveries.status = 'failed';
+
+-- name: UpdateOrCreateReminderDelivery :exec
+INSERT INTO
+  reminder_deliveries (
+    attempts,
+    bookmark_id,
+    created_at,
+    due_at,
+    error,
+    lease_owner,
+    next_attempt_at,
+    status,
+    updated_at
+  )
+VALUES
+  (?, ?, ?, ?, ?, '', ?, ?, ?)
+ON CONFLICT (bookmark_id, due_at) DO UPDATE
+SET
+  attempts = excluded.attempts,
+  error = excluded.error,
+  lease_expires_at = NULL,
+  lease_owner = '',
+  next_attempt_at = excluded.next_attempt_at,
+  status = excluded.status,
+  updated_at = excluded.updated_at;
+
+-- name: ClaimReminderDelivery :exec
+INSERT INTO
+  reminder_deliveries (
+    attempts,
+    bookmark_id,
+    created_at,
+    due_at,
+    error,
+    lease_expires_at,
+    lease_owner,
+    status,
+    updated_at
+  )
+VALUES
+  (0, ?, ?, ?, '', ?, ?, 'sending', ?)
+ON CONFLICT (bookmark_id, due_at) DO UPDATE
+SET
+  lease_expires_at = excluded.lease_expires_at,
+  lease_owner = excluded.lease_owner,
+  status = 'sending',
+  updated_at = excluded.updated_at;
+
+-- name: ListHeldBookmarksForUser :many
+SELECT
+  bookmarks.*
+FROM
+  bookmarks
+  JOIN reminder_deliveries ON reminder_deliveries.bookmark_id = bookmarks.id
+  AND reminder_deliveries.due_at = bookmarks.due_at
+WHERE
+  bookmarks.user_id = ?
+  AND reminder_deliveries.status = 'held'
+ORDER BY
+  bookmarks.due_at,
+  bookmarks.id;
+
+-- name: ListUpcomingBookmarksForUser :many
+SELECT
+  *
+FROM
+  bookmarks
+WHERE
+  user_id = ?
+  AND due_at > sqlc.arg(now)
+  AND due_at <= sqlc.arg(until)
+ORDER BY
+  due_at,
+  id;
+
+-- name: ReleaseHeldReminderDeliveries :many
+UPDATE reminder_deliveries
+SET
+  next_attempt_at = sqlc.arg(now),
+  status = 'pending',
+  updated_at = sqlc.arg(now)
+WHERE
+  status = 'held'
+  AND bookmark_id IN (
+    SELECT
+      id
+    FROM
+      bookmarks
+    WHERE
+      user_id = sqlc.arg(user_id)
+  ) RETURNING bookmark_id;
+
+-- name: CountUsers :one
+SELECT
+  COUNT(*)
+FROM
+  (
+    SELECT
+      user_id
+    FROM
+      bookmarks
+    UNION
+    SELECT
+      user_id
+    FROM
+      user_settings
+  ) AS users;
+
+-- name: CountAllBookmarks :one
+SELECT
+  COUNT(*)
+FROM
+  bookmarks;
+
+-- name: CountAllReminders :one
+SELECT
+  COUNT(*)
+FROM
+  bookmarks
+WHERE
+  due_at IS NOT NULL;
+
+-- name: DeleteBookmarksForUser :many
+DELETE FROM bookmarks
+WHERE
+  user_id = ? RETURNING id;
+
+-- name: DeletePendingBookmarksForUser :exec
+DELETE FROM pending_bookmarks
+WHERE
+  user_id = ?;
+
+-- name: DeleteTagsForUser :exec
+DELETE FROM tags
+WHERE
+  user_id = ?;
+
+-- name: DeleteBookmarksByIDForUser :many
+DELETE FROM bookmarks
+WHERE
+  user_id = ?
+  AND id IN (sqlc.slice('ids')) RETURNING id;
+
+-- name: DeleteBookmarksCreatedBeforeForUser :many
+DELETE FROM bookmarks
+WHERE
+  user_id = ?
+  AND created_at < ? RETURNING id;
+
+-- name: DeleteBookmarksWithoutReminderForUser :many
+DELETE FROM bookmarks
+WHERE
+  user_id = ?
+  AND due_at IS NULL RETURNING id;
@@ -8,88 +8,1212 @@ package queries
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 )
 
+const addBookmarkTag = `-- name: AddBookmarkTag :exec
+INSERT INTO
+  bookmark_tags (bookmark_id, tag_id)
+VALUES
+  (?, ?)
+ON CONFLICT (bookmark_id, tag_id) DO NOTHING
+`
+
+type AddBookmarkTagParams struct {
+	BookmarkID int64
+	TagID      int64
+}
+
+func (q *Queries) AddBookmarkTag(ctx context.Context, arg AddBookmarkTagParams) error {
+	_, err := q.db.ExecContext(ctx, addBookmarkTag, arg.BookmarkID, arg.TagID)
+	return err
+}
+
+const claimReminderDelivery = `-- name: ClaimReminderDelivery :exec
+INSERT INTO
+  reminder_deliveries (
+    attempts,
+    bookmark_id,
+    created_at,
+    due_at,
+    error,
+    lease_expires_at,
+    lease_owner,
+    status,
+    updated_at
+  )
+VALUES
+  (0, ?, ?, ?, '', ?, ?, 'sending', ?)
+ON CONFLICT (bookmark_id, due_at) DO UPDATE
+SET
+  lease_expires_at = excluded.lease_expires_at,
+  lease_owner = excluded.lease_owner,
+  status = 'sending',
+  updated_at = excluded.updated_at
+`
+
+type ClaimReminderDeliveryParams struct {
+	BookmarkID     int64
+	CreatedAt      time.Time
+	DueAt          time.Time
+	LeaseExpiresAt sql.NullTime
+	LeaseOwner     string
+	UpdatedAt      time.Time
+}
+
+func (q *Queries) ClaimReminderDelivery(ctx context.Context, arg ClaimReminderDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, claimReminderDelivery,
+		arg.BookmarkID,
+		arg.CreatedAt,
+		arg.DueAt,
+		arg.LeaseExpiresAt,
+		arg.LeaseOwner,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const clearBookmarkReminder = `-- name: ClearBookmarkReminder :exec
+Update bookmarks
+SET
+  due_at = NULL,
+  recurrence = '',
+  recurrence_anchor = NULL
+WHERE
+  id = ?
+`
+
+func (q *Queries) ClearBookmarkReminder(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, clearBookmarkReminder, id)
+	return err
+}
+
+const clearBookmarkReminderForUser = `-- name: ClearBookmarkReminderForUser :execrows
+Update bookmarks
+SET
+  due_at = NULL,
+  recurrence = '',
+  recurrence_anchor = NULL
+WHERE
+  id = ?
+  AND user_id = ?
+`
+
+type ClearBookmarkReminderForUserParams struct {
+	ID     int64
+	UserID string
+}
+
+func (q *Queries) ClearBookmarkReminderForUser(ctx context.Context, arg ClearBookmarkReminderForUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, clearBookmarkReminderForUser, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countAllBookmarks = `-- name: CountAllBookmarks :one
+SELECT
+  COUNT(*)
+FROM
+  bookmarks
+`
+
+func (q *Queries) CountAllBookmarks(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAllBookmarks)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countAllReminders = `-- name: CountAllReminders :one
+SELECT
+  COUNT(*)
+FROM
+  bookmarks
+WHERE
+  due_at IS NOT NULL
+`
+
+func (q *Queries) CountAllReminders(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAllReminders)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countBookmarks = `-- name: CountBookmarks :one
 SELECT
   COUNT(ID)
 FROM
   bookmarks
 WHERE
-  user_id = ?
+  user_id = ?
+`
+
+func (q *Queries) CountBookmarks(ctx context.Context, userID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countBookmarks, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT
+  COUNT(*)
+FROM
+  (
+    SELECT
+      user_id
+    FROM
+      bookmarks
+    UNION
+    SELECT
+      user_id
+    FROM
+      user_settings
+  ) AS users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createPendingBookmark = `-- name: CreatePendingBookmark :execlastid
+INSERT INTO
+  pending_bookmarks (
+    author_id,
+    channel_id,
+    content,
+    created_at,
+    expires_at,
+    guild_id,
+    message_id,
+    timestamp,
+    user_id
+  )
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreatePendingBookmarkParams struct {
+	AuthorID  string
+	ChannelID string
+	Content   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	GuildID   string
+	MessageID string
+	Timestamp time.Time
+	UserID    string
+}
+
+func (q *Queries) CreatePendingBookmark(ctx context.Context, arg CreatePendingBookmarkParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPendingBookmark,
+		arg.AuthorID,
+		arg.ChannelID,
+		arg.Content,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+		arg.GuildID,
+		arg.MessageID,
+		arg.Timestamp,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const createTag = `-- name: CreateTag :exec
+INSERT INTO
+  tags (name, user_id)
+VALUES
+  (?, ?)
+ON CONFLICT (user_id, name) DO NOTHING
+`
+
+type CreateTagParams struct {
+	Name   string
+	UserID string
+}
+
+func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) error {
+	_, err := q.db.ExecContext(ctx, createTag, arg.Name, arg.UserID)
+	return err
+}
+
+const deleteAllBookmarks = `-- name: DeleteAllBookmarks :exec
+DELETE FROM bookmarks
+`
+
+func (q *Queries) DeleteAllBookmarks(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllBookmarks)
+	return err
+}
+
+const deleteBookmark = `-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE
+  id = ?
+  AND user_id = ?
+`
+
+type DeleteBookmarkParams struct {
+	ID     int64
+	UserID string
+}
+
+func (q *Queries) DeleteBookmark(ctx context.Context, arg DeleteBookmarkParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBookmark, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteBookmarkTag = `-- name: DeleteBookmarkTag :execrows
+DELETE FROM bookmark_tags
+WHERE
+  bookmark_id = ?
+  AND tag_id IN (
+    SELECT
+      id
+    FROM
+      tags
+    WHERE
+      user_id = ?
+      AND name = ?
+  )
+`
+
+type DeleteBookmarkTagParams struct {
+	BookmarkID int64
+	UserID     string
+	Name       string
+}
+
+func (q *Queries) DeleteBookmarkTag(ctx context.Context, arg DeleteBookmarkTagParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBookmarkTag, arg.BookmarkID, arg.UserID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteBookmarkTags = `-- name: DeleteBookmarkTags :exec
+DELETE FROM bookmark_tags
+WHERE
+  bookmark_id = ?
+`
+
+func (q *Queries) DeleteBookmarkTags(ctx context.Context, bookmarkID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteBookmarkTags, bookmarkID)
+	return err
+}
+
+const deleteBookmarksByIDForUser = `-- name: DeleteBookmarksByIDForUser :many
+DELETE FROM bookmarks
+WHERE
+  user_id = ?
+  AND id IN (/*SLICE:ids*/?) RETURNING id
+`
+
+type DeleteBookmarksByIDForUserParams struct {
+	UserID string
+	Ids    []int64
+}
+
+func (q *Queries) DeleteBookmarksByIDForUser(ctx context.Context, arg DeleteBookmarksByIDForUserParams) ([]int64, error) {
+	query := deleteBookmarksByIDForUser
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.Ids) > 0 {
+		for _, v := range arg.Ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(arg.Ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteBookmarksCreatedBeforeForUser = `-- name: DeleteBookmarksCreatedBeforeForUser :many
+DELETE FROM bookmarks
+WHERE
+  user_id = ?
+  AND created_at < ? RETURNING id
+`
+
+type DeleteBookmarksCreatedBeforeForUserParams struct {
+	UserID    string
+	CreatedAt time.Time
+}
+
+func (q *Queries) DeleteBookmarksCreatedBeforeForUser(ctx context.Context, arg DeleteBookmarksCreatedBeforeForUserParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, deleteBookmarksCreatedBeforeForUser, arg.UserID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteBookmarksForUser = `-- name: DeleteBookmarksForUser :many
+DELETE FROM bookmarks
+WHERE
+  user_id = ? RETURNING id
+`
+
+func (q *Queries) DeleteBookmarksForUser(ctx context.Context, userID string) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, deleteBookmarksForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteBookmarksWithoutReminderForUser = `-- name: DeleteBookmarksWithoutReminderForUser :many
+DELETE FROM bookmarks
+WHERE
+  user_id = ?
+  AND due_at IS NULL RETURNING id
+`
+
+func (q *Queries) DeleteBookmarksWithoutReminderForUser(ctx context.Context, userID string) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, deleteBookmarksWithoutReminderForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteExpiredPendingBookmarks = `-- name: DeleteExpiredPendingBookmarks :execrows
+DELETE FROM pending_bookmarks
+WHERE
+  expires_at <= ?1
+`
+
+func (q *Queries) DeleteExpiredPendingBookmarks(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredPendingBookmarks, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePendingBookmark = `-- name: DeletePendingBookmark :exec
+DELETE FROM pending_bookmarks
+WHERE
+  id = ?
+`
+
+func (q *Queries) DeletePendingBookmark(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deletePendingBookmark, id)
+	return err
+}
+
+const deletePendingBookmarksForUser = `-- name: DeletePendingBookmarksForUser :exec
+DELETE FROM pending_bookmarks
+WHERE
+  user_id = ?
+`
+
+func (q *Queries) DeletePendingBookmarksForUser(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deletePendingBookmarksForUser, userID)
+	return err
+}
+
+const deleteTagsForUser = `-- name: DeleteTagsForUser :exec
+DELETE FROM tags
+WHERE
+  user_id = ?
+`
+
+func (q *Queries) DeleteTagsForUser(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteTagsForUser, userID)
+	return err
+}
+
+const deleteUnusedTags = `-- name: DeleteUnusedTags :exec
+DELETE FROM tags
+WHERE
+  user_id = ?
+  AND id NOT IN (
+    SELECT
+      tag_id
+    FROM
+      bookmark_tags
+  )
+`
+
+func (q *Queries) DeleteUnusedTags(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteUnusedTags, userID)
+	return err
+}
+
+const deleteUserSettings = `-- name: DeleteUserSettings :exec
+DELETE FROM user_settings
+WHERE
+  user_id = ?
+`
+
+func (q *Queries) DeleteUserSettings(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteUserSettings, userID)
+	return err
+}
+
+const getBookmark = `-- name: GetBookmark :one
+SELECT
+  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, note, recurrence, recurrence_anchor, timestamp, updated_at, user_id
+FROM
+  bookmarks
+WHERE
+  id = ?
+LIMIT
+  1
+`
+
+func (q *Queries) GetBookmark(ctx context.Context, id int64) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, getBookmark, id)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.AuthorID,
+		&i.ChannelID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.DueAt,
+		&i.GuildID,
+		&i.MessageID,
+		&i.Note,
+		&i.Recurrence,
+		&i.RecurrenceAnchor,
+		&i.Timestamp,
+		&i.UpdatedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const getBookmarkForUser = `-- name: GetBookmarkForUser :one
+SELECT
+  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, note, recurrence, recurrence_anchor, timestamp, updated_at, user_id
+FROM
+  bookmarks
+WHERE
+  id = ?
+  AND user_id = ?
+LIMIT
+  1
+`
+
+type GetBookmarkForUserParams struct {
+	ID     int64
+	UserID string
+}
+
+func (q *Queries) GetBookmarkForUser(ctx context.Context, arg GetBookmarkForUserParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, getBookmarkForUser, arg.ID, arg.UserID)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.AuthorID,
+		&i.ChannelID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.DueAt,
+		&i.GuildID,
+		&i.MessageID,
+		&i.Note,
+		&i.Recurrence,
+		&i.RecurrenceAnchor,
+		&i.Timestamp,
+		&i.UpdatedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const getBookmarkIDForMessage = `-- name: GetBookmarkIDForMessage :one
+SELECT
+  id
+FROM
+  bookmarks
+WHERE
+  channel_id = ?
+  AND guild_id = ?
+  AND message_id = ?
+  AND user_id = ?
+`
+
+type GetBookmarkIDForMessageParams struct {
+	ChannelID string
+	GuildID   string
+	MessageID string
+	UserID    string
+}
+
+func (q *Queries) GetBookmarkIDForMessage(ctx context.Context, arg GetBookmarkIDForMessageParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getBookmarkIDForMessage,
+		arg.ChannelID,
+		arg.GuildID,
+		arg.MessageID,
+		arg.UserID,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getFailedReminderDelivery = `-- name: GetFailedReminderDelivery :one
+SELECT
+  reminder_deliveries.id, reminder_deliveries.attempts, reminder_deliveries.bookmark_id, reminder_deliveries.created_at, reminder_deliveries.due_at, reminder_deliveries.error, reminder_deliveries.lease_expires_at, reminder_deliveries.lease_owner, reminder_deliveries.next_attempt_at, reminder_deliveries.status, reminder_deliveries.updated_at
+FROM
+  reminder_deliveries
+  JOIN bookmarks ON bookmarks.id = reminder_deliveries.bookmark_id
+  AND bookmarks.due_at = reminder_deliveries.due_at
+WHERE
+  reminder_deliveries.bookmark_id = ?
+  AND reminder_deliveries.status = 'failed'
+`
+
+func (q *Queries) GetFailedReminderDelivery(ctx context.Context, bookmarkID int64) (ReminderDelivery, error) {
+	row := q.db.QueryRowContext(ctx, getFailedReminderDelivery, bookmarkID)
+	var i ReminderDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.Attempts,
+		&i.BookmarkID,
+		&i.CreatedAt,
+		&i.DueAt,
+		&i.Error,
+		&i.LeaseExpiresAt,
+		&i.LeaseOwner,
+		&i.NextAttemptAt,
+		&i.Status,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPendingBookmark = `-- name: GetPendingBookmark :one
+SELECT
+  id, author_id, channel_id, content, created_at, expires_at, guild_id, message_id, timestamp, user_id
+FROM
+  pending_bookmarks
+WHERE
+  id = ?
+  AND user_id = ?
+  AND expires_at > ?3
+`
+
+type GetPendingBookmarkParams struct {
+	ID     int64
+	UserID string
+	Now    time.Time
+}
+
+func (q *Queries) GetPendingBookmark(ctx context.Context, arg GetPendingBookmarkParams) (PendingBookmark, error) {
+	row := q.db.QueryRowContext(ctx, getPendingBookmark, arg.ID, arg.UserID, arg.Now)
+	var i PendingBookmark
+	err := row.Scan(
+		&i.ID,
+		&i.AuthorID,
+		&i.ChannelID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.GuildID,
+		&i.MessageID,
+		&i.Timestamp,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const getReminderDelivery = `-- name: GetReminderDelivery :one
+SELECT
+  id, attempts, bookmark_id, created_at, due_at, error, lease_expires_at, lease_owner, next_attempt_at, status, updated_at
+FROM
+  reminder_deliveries
+WHERE
+  bookmark_id = ?
+  AND due_at = ?
+`
+
+type GetReminderDeliveryParams struct {
+	BookmarkID int64
+	DueAt      time.Time
+}
+
+func (q *Queries) GetReminderDelivery(ctx context.Context, arg GetReminderDeliveryParams) (ReminderDelivery, error) {
+	row := q.db.QueryRowContext(ctx, getReminderDelivery, arg.BookmarkID, arg.DueAt)
+	var i ReminderDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.Attempts,
+		&i.BookmarkID,
+		&i.CreatedAt,
+		&i.DueAt,
+		&i.Error,
+		&i.LeaseExpiresAt,
+		&i.LeaseOwner,
+		&i.NextAttemptAt,
+		&i.Status,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTagID = `-- name: GetTagID :one
+SELECT
+  id
+FROM
+  tags
+WHERE
+  user_id = ?
+  AND name = ?
+`
+
+type GetTagIDParams struct {
+	UserID string
+	Name   string
+}
+
+func (q *Queries) GetTagID(ctx context.Context, arg GetTagIDParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTagID, arg.UserID, arg.Name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getUserSettings = `-- name: GetUserSettings :one
+SELECT
+  user_id, created_at, default_reminder, digest_hour, digest_mode, digest_weekday, list_page_size, locale, quiet_hours_end, quiet_hours_start, timezone, updated_at
+FROM
+  user_settings
+WHERE
+  user_id = ?
+`
+
+func (q *Queries) GetUserSettings(ctx context.Context, userID string) (UserSetting, error) {
+	row := q.db.QueryRowContext(ctx, getUserSettings, userID)
+	var i UserSetting
+	err := row.Scan(
+		&i.UserID,
+		&i.CreatedAt,
+		&i.DefaultReminder,
+		&i.DigestHour,
+		&i.DigestMode,
+		&i.DigestWeekday,
+		&i.ListPageSize,
+		&i.Locale,
+		&i.QuietHoursEnd,
+		&i.QuietHoursStart,
+		&i.Timezone,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listBookmarksForUser = `-- name: ListBookmarksForUser :many
+SELECT
+  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, note, recurrence, recurrence_anchor, timestamp, updated_at, user_id
+FROM
+  bookmarks
+WHERE
+  user_id = ?
+ORDER BY
+  guild_id, channel_id, timestamp
+`
+
+func (q *Queries) ListBookmarksForUser(ctx context.Context, userID string) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarksForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.AuthorID,
+			&i.ChannelID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.DueAt,
+			&i.GuildID,
+			&i.MessageID,
+			&i.Note,
+			&i.Recurrence,
+			&i.RecurrenceAnchor,
+			&i.Timestamp,
+			&i.UpdatedAt,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarksMatchingForUser = `-- name: ListBookmarksMatchingForUser :many
+SELECT
+  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, note, recurrence, recurrence_anchor, timestamp, updated_at, user_id
+FROM
+  bookmarks
+WHERE
+  user_id = ?1
+  AND (
+    instr(CAST(id AS TEXT), ?2) = 1
+    OR instr(lower(content), lower(?2)) > 0
+  )
+ORDER BY
+  guild_id, channel_id, timestamp
+LIMIT
+  ?3
+`
+
+type ListBookmarksMatchingForUserParams struct {
+	UserID string
+	Input  string
+	Limit  int64
+}
+
+func (q *Queries) ListBookmarksMatchingForUser(ctx context.Context, arg ListBookmarksMatchingForUserParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarksMatchingForUser, arg.UserID, arg.Input, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.AuthorID,
+			&i.ChannelID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.DueAt,
+			&i.GuildID,
+			&i.MessageID,
+			&i.Note,
+			&i.Recurrence,
+			&i.RecurrenceAnchor,
+			&i.Timestamp,
+			&i.UpdatedAt,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDigestUserSettings = `-- name: ListDigestUserSettings :many
+SELECT
+  user_id, created_at, default_reminder, digest_hour, digest_mode, digest_weekday, list_page_size, locale, quiet_hours_end, quiet_hours_start, timezone, updated_at
+FROM
+  user_settings
+WHERE
+  digest_mode != ''
+`
+
+func (q *Queries) ListDigestUserSettings(ctx context.Context) ([]UserSetting, error) {
+	rows, err := q.db.QueryContext(ctx, listDigestUserSettings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserSetting
+	for rows.Next() {
+		var i UserSetting
+		if err := rows.Scan(
+			&i.UserID,
+			&i.CreatedAt,
+			&i.DefaultReminder,
+			&i.DigestHour,
+			&i.DigestMode,
+			&i.DigestWeekday,
+			&i.ListPageSize,
+			&i.Locale,
+			&i.QuietHoursEnd,
+			&i.QuietHoursStart,
+			&i.Timezone,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueBookmarks = `-- name: ListDueBookmarks :many
+SELECT
+  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, note, recurrence, recurrence_anchor, timestamp, updated_at, user_id
+FROM
+  bookmarks
+WHERE
+  due_at IS NOT NULL
+  AND due_at <= ?1
+  AND NOT EXISTS (
+    SELECT
+      1
+    FROM
+      reminder_deliveries
+    WHERE
+      reminder_deliveries.bookmark_id = bookmarks.id
+      AND reminder_deliveries.due_at = bookmarks.due_at
+      AND (
+        reminder_deliveries.status NOT IN ('deferred', 'pending', 'sending')
+        OR (
+          reminder_deliveries.status IN ('deferred', 'pending')
+          AND reminder_deliveries.next_attempt_at > ?1
+        )
+        OR (
+          reminder_deliveries.status = 'sending'
+          AND reminder_deliveries.lease_expires_at > ?1
+        )
+      )
+  )
+`
+
+func (q *Queries) ListDueBookmarks(ctx context.Context, dueAt sql.NullTime) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listDueBookmarks, dueAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.AuthorID,
+			&i.ChannelID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.DueAt,
+			&i.GuildID,
+			&i.MessageID,
+			&i.Note,
+			&i.Recurrence,
+			&i.RecurrenceAnchor,
+			&i.Timestamp,
+			&i.UpdatedAt,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHeldBookmarksForUser = `-- name: ListHeldBookmarksForUser :many
+SELECT
+  bookmarks.id, bookmarks.author_id, bookmarks.channel_id, bookmarks.content, bookmarks.created_at, bookmarks.due_at, bookmarks.guild_id, bookmarks.message_id, bookmarks.note, bookmarks.recurrence, bookmarks.recurrence_anchor, bookmarks.timestamp, bookmarks.updated_at, bookmarks.user_id
+FROM
+  bookmarks
+  JOIN reminder_deliveries ON reminder_deliveries.bookmark_id = bookmarks.id
+  AND reminder_deliveries.due_at = bookmarks.due_at
+WHERE
+  bookmarks.user_id = ?
+  AND reminder_deliveries.status = 'held'
+ORDER BY
+  bookmarks.due_at,
+  bookmarks.id
 `
 
-func (q *Queries) CountBookmarks(ctx context.Context, userID string) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countBookmarks, userID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+func (q *Queries) ListHeldBookmarksForUser(ctx context.Context, userID string) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listHeldBookmarksForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.AuthorID,
+			&i.ChannelID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.DueAt,
+			&i.GuildID,
+			&i.MessageID,
+			&i.Note,
+			&i.Recurrence,
+			&i.RecurrenceAnchor,
+			&i.Timestamp,
+			&i.UpdatedAt,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const deleteAllBookmarks = `-- name: DeleteAllBookmarks :exec
-DELETE FROM bookmarks
+const listReminders = `-- name: ListReminders :many
+SELECT
+  bookmarks.id,
+  bookmarks.due_at,
+  reminder_deliveries.lease_expires_at,
+  reminder_deliveries.next_attempt_at,
+  reminder_deliveries.status
+FROM
+  bookmarks
+  LEFT JOIN reminder_deliveries ON reminder_deliveries.bookmark_id = bookmarks.id
+  AND reminder_deliveries.due_at = bookmarks.due_at
+WHERE
+  bookmarks.due_at IS NOT NULL
 `
 
-func (q *Queries) DeleteAllBookmarks(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, deleteAllBookmarks)
-	return err
+type ListRemindersRow struct {
+	ID             int64
+	DueAt          sql.NullTime
+	LeaseExpiresAt sql.NullTime
+	NextAttemptAt  sql.NullTime
+	Status         sql.NullString
 }
 
-const deleteBookmark = `-- name: DeleteBookmark :exec
-DELETE FROM bookmarks
+func (q *Queries) ListReminders(ctx context.Context) ([]ListRemindersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReminders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRemindersRow
+	for rows.Next() {
+		var i ListRemindersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DueAt,
+			&i.LeaseExpiresAt,
+			&i.NextAttemptAt,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagsForBookmark = `-- name: ListTagsForBookmark :many
+SELECT
+  tags.name
+FROM
+  tags
+  JOIN bookmark_tags ON bookmark_tags.tag_id = tags.id
 WHERE
-  id = ?
+  bookmark_tags.bookmark_id = ?
+ORDER BY
+  tags.name
 `
 
-func (q *Queries) DeleteBookmark(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteBookmark, id)
-	return err
+func (q *Queries) ListTagsForBookmark(ctx context.Context, bookmarkID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listTagsForBookmark, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getBookmark = `-- name: GetBookmark :one
+const listTagsForUser = `-- name: ListTagsForUser :many
 SELECT
-  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, timestamp, updated_at, user_id
+  tags.name,
+  COUNT(bookmark_tags.bookmark_id) AS bookmarks
 FROM
-  bookmarks
+  tags
+  JOIN bookmark_tags ON bookmark_tags.tag_id = tags.id
 WHERE
-  id = ?
-LIMIT
-  1
+  tags.user_id = ?
+GROUP BY
+  tags.id
+ORDER BY
+  tags.name
 `
 
-func (q *Queries) GetBookmark(ctx context.Context, id int64) (Bookmark, error) {
-	row := q.db.QueryRowContext(ctx, getBookmark, id)
-	var i Bookmark
-	err := row.Scan(
-		&i.ID,
-		&i.AuthorID,
-		&i.ChannelID,
-		&i.Content,
-		&i.CreatedAt,
-		&i.DueAt,
-		&i.GuildID,
-		&i.MessageID,
-		&i.Timestamp,
-		&i.UpdatedAt,
-		&i.UserID,
-	)
-	return i, err
+type ListTagsForUserRow struct {
+	Name      string
+	Bookmarks int64
 }
 
-const listBookmarksForUser = `-- name: ListBookmarksForUser :many
+func (q *Queries) ListTagsForUser(ctx context.Context, userID string) ([]ListTagsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTagsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsForUserRow
+	for rows.Next() {
+		var i ListTagsForUserRow
+		if err := rows.Scan(&i.Name, &i.Bookmarks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUpcomingBookmarksForUser = `-- name: ListUpcomingBookmarksForUser :many
 SELECT
-  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, timestamp, updated_at, user_id
+  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, note, recurrence, recurrence_anchor, timestamp, updated_at, user_id
 FROM
   bookmarks
 WHERE
   user_id = ?
+  AND due_at > ?2
+  AND due_at <= ?3
 ORDER BY
-  guild_id, channel_id, timestamp
+  due_at,
+  id
 `
 
-func (q *Queries) ListBookmarksForUser(ctx context.Context, userID string) ([]Bookmark, error) {
-	rows, err := q.db.QueryContext(ctx, listBookmarksForUser, userID)
+type ListUpcomingBookmarksForUserParams struct {
+	UserID string
+	Now    sql.NullTime
+	Until  sql.NullTime
+}
+
+func (q *Queries) ListUpcomingBookmarksForUser(ctx context.Context, arg ListUpcomingBookmarksForUserParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listUpcomingBookmarksForUser, arg.UserID, arg.Now, arg.Until)
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +1230,9 @@ func (q *Queries) ListBookmarksForUser(ctx context.Context, userID string) ([]Bo
 			&i.DueAt,
 			&i.GuildID,
 			&i.MessageID,
+			&i.Note,
+			&i.Recurrence,
+			&i.RecurrenceAnchor,
 			&i.Timestamp,
 			&i.UpdatedAt,
 			&i.UserID,
@@ -123,41 +1250,42 @@ func (q *Queries) ListBookmarksForUser(ctx context.Context, userID string) ([]Bo
 	return items, nil
 }
 
-const listDueBookmarks = `-- name: ListDueBookmarks :many
-SELECT
-  id, author_id, channel_id, content, created_at, due_at, guild_id, message_id, timestamp, updated_at, user_id
-FROM
-  bookmarks
+const releaseHeldReminderDeliveries = `-- name: ReleaseHeldReminderDeliveries :many
+UPDATE reminder_deliveries
+SET
+  next_attempt_at = ?1,
+  status = 'pending',
+  updated_at = ?1
 WHERE
-  due_at IS NOT NULL
-  AND due_at < ?1
+  status = 'held'
+  AND bookmark_id IN (
+    SELECT
+      id
+    FROM
+      bookmarks
+    WHERE
+      user_id = ?2
+  ) RETURNING bookmark_id
 `
 
-func (q *Queries) ListDueBookmarks(ctx context.Context, now sql.NullTime) ([]Bookmark, error) {
-	rows, err := q.db.QueryContext(ctx, listDueBookmarks, now)
+type ReleaseHeldReminderDeliveriesParams struct {
+	Now    sql.NullTime
+	UserID string
+}
+
+func (q *Queries) ReleaseHeldReminderDeliveries(ctx context.Context, arg ReleaseHeldReminderDeliveriesParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, releaseHeldReminderDeliveries, arg.Now, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Bookmark
+	var items []int64
 	for rows.Next() {
-		var i Bookmark
-		if err := rows.Scan(
-			&i.ID,
-			&i.AuthorID,
-			&i.ChannelID,
-			&i.Content,
-			&i.CreatedAt,
-			&i.DueAt,
-			&i.GuildID,
-			&i.MessageID,
-			&i.Timestamp,
-			&i.UpdatedAt,
-			&i.UserID,
-		); err != nil {
+		var bookmark_id int64
+		if err := rows.Scan(&bookmark_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, bookmark_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -168,6 +1296,29 @@ func (q *Queries) ListDueBookmarks(ctx context.Context, now sql.NullTime) ([]Boo
 	return items, nil
 }
 
+const snoozeBookmarkForUser = `-- name: SnoozeBookmarkForUser :execrows
+Update bookmarks
+SET
+  due_at = ?
+WHERE
+  id = ?
+  AND user_id = ?
+`
+
+type SnoozeBookmarkForUserParams struct {
+	DueAt  sql.NullTime
+	ID     int64
+	UserID string
+}
+
+func (q *Queries) SnoozeBookmarkForUser(ctx context.Context, arg SnoozeBookmarkForUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, snoozeBookmarkForUser, arg.DueAt, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateBookmarkDueAt = `-- name: UpdateBookmarkDueAt :exec
 Update bookmarks
 SET
@@ -186,7 +1337,85 @@ func (q *Queries) UpdateBookmarkDueAt(ctx context.Context, arg UpdateBookmarkDue
 	return err
 }
 
-const updateOrCreateBookmark = `-- name: UpdateOrCreateBookmark :execlastid
+const updateBookmarkDueAtForUser = `-- name: UpdateBookmarkDueAtForUser :execrows
+Update bookmarks
+SET
+  due_at = ?1,
+  recurrence_anchor = ?1
+WHERE
+  id = ?2
+  AND user_id = ?3
+`
+
+type UpdateBookmarkDueAtForUserParams struct {
+	DueAt  sql.NullTime
+	ID     int64
+	UserID string
+}
+
+func (q *Queries) UpdateBookmarkDueAtForUser(ctx context.Context, arg UpdateBookmarkDueAtForUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateBookmarkDueAtForUser, arg.DueAt, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateBookmarkNoteForUser = `-- name: UpdateBookmarkNoteForUser :execrows
+Update bookmarks
+SET
+  note = ?,
+  updated_at = ?
+WHERE
+  id = ?
+  AND user_id = ?
+`
+
+type UpdateBookmarkNoteForUserParams struct {
+	Note      sql.NullString
+	UpdatedAt time.Time
+	ID        int64
+	UserID    string
+}
+
+func (q *Queries) UpdateBookmarkNoteForUser(ctx context.Context, arg UpdateBookmarkNoteForUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateBookmarkNoteForUser,
+		arg.Note,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateBookmarkRecurrenceForUser = `-- name: UpdateBookmarkRecurrenceForUser :execrows
+Update bookmarks
+SET
+  recurrence = ?,
+  recurrence_anchor = due_at
+WHERE
+  id = ?
+  AND user_id = ?
+`
+
+type UpdateBookmarkRecurrenceForUserParams struct {
+	Recurrence string
+	ID         int64
+	UserID     string
+}
+
+func (q *Queries) UpdateBookmarkRecurrenceForUser(ctx context.Context, arg UpdateBookmarkRecurrenceForUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateBookmarkRecurrenceForUser, arg.Recurrence, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateOrCreateBookmark = `-- name: UpdateOrCreateBookmark :exec
 INSERT INTO
   bookmarks (
     author_id,
@@ -206,6 +1435,11 @@ ON CONFLICT (channel_id, guild_id, message_id, user_id) DO UPDATE
 SET
   created_at = ?4,
   due_at = ?5,
+  recurrence = CASE
+    WHEN ?5 IS NULL THEN ''
+    ELSE recurrence
+  END,
+  recurrence_anchor = ?5,
   updated_at = ?9
 `
 
@@ -222,8 +1456,8 @@ type UpdateOrCreateBookmarkParams struct {
 	UserID    string
 }
 
-func (q *Queries) UpdateOrCreateBookmark(ctx context.Context, arg UpdateOrCreateBookmarkParams) (int64, error) {
-	result, err := q.db.ExecContext(ctx, updateOrCreateBookmark,
+func (q *Queries) UpdateOrCreateBookmark(ctx context.Context, arg UpdateOrCreateBookmarkParams) error {
+	_, err := q.db.ExecContext(ctx, updateOrCreateBookmark,
 		arg.AuthorID,
 		arg.ChannelID,
 		arg.Content,
@@ -235,8 +1469,121 @@ func (q *Queries) UpdateOrCreateBookmark(ctx context.Context, arg UpdateOrCreate
 		arg.UpdatedAt,
 		arg.UserID,
 	)
-	if err != nil {
-		return 0, err
-	}
-	return result.LastInsertId()
+	return err
+}
+
+const updateOrCreateReminderDelivery = `-- name: UpdateOrCreateReminderDelivery :exec
+INSERT INTO
+  reminder_deliveries (
+    attempts,
+    bookmark_id,
+    created_at,
+    due_at,
+    error,
+    lease_owner,
+    next_attempt_at,
+    status,
+    updated_at
+  )
+VALUES
+  (?, ?, ?, ?, ?, '', ?, ?, ?)
+ON CONFLICT (bookmark_id, due_at) DO UPDATE
+SET
+  attempts = excluded.attempts,
+  error = excluded.error,
+  lease_expires_at = NULL,
+  lease_owner = '',
+  next_attempt_at = excluded.next_attempt_at,
+  status = excluded.status,
+  updated_at = excluded.updated_at
+`
+
+type UpdateOrCreateReminderDeliveryParams struct {
+	Attempts      int64
+	BookmarkID    int64
+	CreatedAt     time.Time
+	DueAt         time.Time
+	Error         string
+	NextAttemptAt sql.NullTime
+	Status        string
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) UpdateOrCreateReminderDelivery(ctx context.Context, arg UpdateOrCreateReminderDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, updateOrCreateReminderDelivery,
+		arg.Attempts,
+		arg.BookmarkID,
+		arg.CreatedAt,
+		arg.DueAt,
+		arg.Error,
+		arg.NextAttemptAt,
+		arg.Status,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const updateOrCreateUserSettings = `-- name: UpdateOrCreateUserSettings :exec
+INSERT INTO
+  user_settings (
+    user_id,
+    created_at,
+    default_reminder,
+    digest_hour,
+    digest_mode,
+    digest_weekday,
+    list_page_size,
+    locale,
+    quiet_hours_end,
+    quiet_hours_start,
+    timezone,
+    updated_at
+  )
+VALUES
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE
+SET
+  default_reminder = ?3,
+  digest_hour = ?4,
+  digest_mode = ?5,
+  digest_weekday = ?6,
+  list_page_size = ?7,
+  locale = ?8,
+  quiet_hours_end = ?9,
+  quiet_hours_start = ?10,
+  timezone = ?11,
+  updated_at = ?12
+`
+
+type UpdateOrCreateUserSettingsParams struct {
+	UserID          string
+	CreatedAt       time.Time
+	DefaultReminder int64
+	DigestHour      int64
+	DigestMode      string
+	DigestWeekday   int64
+	ListPageSize    int64
+	Locale          string
+	QuietHoursEnd   int64
+	QuietHoursStart int64
+	Timezone        string
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) UpdateOrCreateUserSettings(ctx context.Context, arg UpdateOrCreateUserSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, updateOrCreateUserSettings,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.DefaultReminder,
+		arg.DigestHour,
+		arg.DigestMode,
+		arg.DigestWeekday,
+		arg.ListPageSize,
+		arg.Locale,
+		arg.QuietHoursEnd,
+		arg.QuietHoursStart,
+		arg.Timezone,
+		arg.UpdatedAt,
+	)
+	return err
 }
@@ -7,6 +7,9 @@ CREATE TABLE IF NOT EXISTS bookmarks (
   due_at DATETIME,
   guild_id TEXT NOT NULL,
   message_id TEXT NOT NULL,
+  note TEXT,
+  recurrence TEXT NOT NULL DEFAULT '',
+  recurrence_anchor DATETIME,
   timestamp DATETIME NOT NULL,
   updated_at DATETIME NOT NULL,
   user_id TEXT NOT NULL,
@@ -15,4 +18,96 @@ CREATE TABLE IF NOT EXISTS bookmarks (
 
 CREATE INDEX IF NOT EXISTS reminders_idx_1 ON bookmarks (user_id);
 
-CREATE INDEX IF NOT EXISTS reminders_idx_2 ON bookmarks (due_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS reminders_idx_2 ON bookmarks (due_at);
+
+CREATE TABLE IF NOT EXISTS user_settings (
+  user_id TEXT PRIMARY KEY NOT NULL,
+  created_at DATETIME NOT NULL,
+  default_reminder INTEGER NOT NULL,
+  digest_hour INTEGER NOT NULL DEFAULT 0,
+  digest_mode TEXT NOT NULL DEFAULT '',
+  digest_weekday INTEGER NOT NULL DEFAULT 0,
+  list_page_size INTEGER NOT NULL,
+  locale TEXT NOT NULL,
+  quiet_hours_end INTEGER NOT NULL,
+  quiet_hours_start INTEGER NOT NULL,
+  timezone TEXT NOT NULL,
+  updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pending_bookmarks (
+  id INTEGER PRIMARY KEY,
+  author_id TEXT NOT NULL,
+  channel_id TEXT NOT NULL,
+  content TEXT NOT NULL,
+  created_at DATETIME NOT NULL,
+  expires_at DATETIME NOT NULL,
+  guild_id TEXT NOT NULL,
+  message_id TEXT NOT NULL,
+  timestamp DATETIME NOT NULL,
+  user_id TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pending_bookmarks_idx_1 ON pending_bookmarks (expires_at);
+
+CREATE TABLE IF NOT EXISTS tags (
+  id INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS bookmark_tags (
+  bookmark_id INTEGER NOT NULL REFERENCES bookmarks (id) ON DELETE CASCADE,
+  tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+  PRIMARY KEY (bookmark_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS bookmark_tags_idx_1 ON bookmark_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS reminder_deliveries (
+  id INTEGER PRIMARY KEY,
+  attempts INTEGER NOT NULL,
+  bookmark_id INTEGER NOT NULL REFERENCES bookmarks (id) ON DELETE CASCADE,
+  created_at DATETIME NOT NULL,
+  due_at DATETIME NOT NULL,
+  error TEXT NOT NULL,
+  lease_expires_at DATETIME,
+  lease_owner TEXT NOT NULL,
+  next_attempt_at DATETIME,
+  status TEXT NOT NULL,
+  updated_at DATETIME NOT NULL,
+  UNIQUE (bookmark_id, due_at)
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS bookmarks_fts USING fts5 (content, note, content='bookmarks', content_rowid='id');
+
+CREATE TRIGGER IF NOT EXISTS bookmarks_fts_ai AFTER INSERT ON bookmarks BEGIN
+INSERT INTO
+  bookmarks_fts (rowid, content, note)
+VALUES
+  (new.id, new.content, new.note);
+
+END;
+
+CREATE TRIGGER IF NOT EXISTS bookmarks_fts_ad AFTER DELETE ON bookmarks BEGIN
+INSERT INTO
+  bookmarks_fts (bookmarks_fts, rowid, content, note)
+VALUES
+  ('delete', old.id, old.content, old.note);
+
+END;
+
+CREATE TRIGGER IF NOT EXISTS bookmarks_fts_au AFTER
+UPDATE OF content, note ON bookmarks BEGIN
+INSERT INTO
+  bookmarks_fts (bookmarks_fts, rowid, content, note)
+VALUES
+  ('delete', old.id, old.content, old.note);
+
+INSERT INTO
+  bookmarks_fts (rowid, content, note)
+VALUES
+  (new.id, new.content, new.note);
+
+END;
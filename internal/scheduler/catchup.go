@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// CatchUpMode defines how missed jobs are handled, e.g. after the bot was down.
+type CatchUpMode uint
+
+const (
+	// CatchUpSendAll handles each missed job on its own.
+	CatchUpSendAll CatchUpMode = iota
+	// CatchUpDigest handles all missed jobs together as one digest.
+	CatchUpDigest
+	// CatchUpDrop drops missed jobs which are later than the max lateness
+	// and handles the others on their own.
+	CatchUpDrop
+)
+
+var catchUpModeNames = map[CatchUpMode]string{
+	CatchUpSendAll: "all",
+	CatchUpDigest:  "digest",
+	CatchUpDrop:    "drop",
+}
+
+func (m CatchUpMode) String() string {
+	if s, ok := catchUpModeNames[m]; ok {
+		return s
+	}
+	return "?"
+}
+
+// ParseCatchUpMode returns the catch-up mode for a name, e.g. "digest".
+func ParseCatchUpMode(s string) (CatchUpMode, error) {
+	for m, name := range catchUpModeNames {
+		if name == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid catch-up mode: %q", s)
+}
+
+// DefaultLateAfter is the default for how late a job can be before it counts as missed.
+const DefaultLateAfter = 5 * time.Minute
+
+// CatchUpPolicy defines how jobs are handled which are popped late.
+// The zero value handles all jobs on their own.
+type CatchUpPolicy struct {
+	Mode CatchUpMode
+	// Jobs later than this count as missed. [DefaultLateAfter] is used when zero.
+	LateAfter time.Duration
+	// Missed jobs later than this are dropped in mode [CatchUpDrop].
+	MaxLate time.Duration
+}
+
+// IsMissed reports whether a job is missed when it is late by d.
+func (p CatchUpPolicy) IsMissed(d time.Duration) bool {
+	lateAfter := p.LateAfter
+	if lateAfter == 0 {
+		lateAfter = DefaultLateAfter
+	}
+	return d > lateAfter
+}
+
+// split returns a batch with the jobs split according to the policy.
+func (p CatchUpPolicy) split(now time.Time, jobs []Job) Batch {
+	b := Batch{Now: now}
+	for _, j := range jobs {
+		late := now.Sub(j.DueAt)
+		switch {
+		case !p.IsMissed(late):
+			b.Send = append(b.Send, j)
+		case p.Mode == CatchUpDigest:
+			b.Digest = append(b.Digest, j)
+		case p.Mode == CatchUpDrop && late > p.MaxLate:
+			b.Drop = append(b.Drop, j)
+		default:
+			b.Send = append(b.Send, j)
+		}
+	}
+	return b
+}
+
+// Job is a job which is due.
+type Job struct {
+	ID    int64
+	DueAt time.Time
+}
+
+// Batch contains the jobs which were due at the same time, split by the catch-up policy.
+// Jobs are ordered by due time.
+type Batch struct {
+	Now    time.Time
+	Send   []Job // jobs to handle on their own
+	Digest []Job // missed jobs to handle together
+	Drop   []Job // missed jobs to drop
+}
+
+// IDs returns the IDs of all jobs in the batch.
+func (b Batch) IDs() []int64 {
+	ids := make([]int64, 0, len(b.Send)+len(b.Digest)+len(b.Drop))
+	for _, jobs := range [][]Job{b.Send, b.Digest, b.Drop} {
+		for _, j := range jobs {
+			ids = append(ids, j.ID)
+		}
+	}
+	return ids
+}
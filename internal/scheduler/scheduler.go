@@ -0,0 +1,188 @@
+// Package scheduler contains a scheduler for running jobs at due times.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers. It allows replacing the real time in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is a [Clock] for the real time.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Scheduler keeps the due times of jobs and calls a handler when jobs are due.
+//
+// It sleeps until the earliest due time and is woken up when due times change.
+// It is safe to use concurrently.
+type Scheduler struct {
+	clock   Clock
+	handler func(b Batch)
+	policy  CatchUpPolicy
+	wake    chan struct{}
+
+	mu    sync.Mutex
+	items itemHeap
+	index map[int64]*item
+}
+
+// New returns a new scheduler.
+// The handler is called with a batch of all jobs that are due, split by the catch-up policy.
+// Jobs are removed from the scheduler before the handler is called.
+func New(clock Clock, policy CatchUpPolicy, handler func(b Batch)) *Scheduler {
+	s := &Scheduler{
+		clock:   clock,
+		handler: handler,
+		policy:  policy,
+		wake:    make(chan struct{}, 1),
+		index:   make(map[int64]*item),
+	}
+	return s
+}
+
+// Set sets the due time of a job. It replaces any previous due time for the same ID.
+// A zero due time removes the job.
+func (s *Scheduler) Set(id int64, dueAt time.Time) {
+	if dueAt.IsZero() {
+		s.Remove(id)
+		return
+	}
+	s.mu.Lock()
+	if it, ok := s.index[id]; ok {
+		it.dueAt = dueAt
+		heap.Fix(&s.items, it.index)
+	} else {
+		it := &item{id: id, dueAt: dueAt}
+		heap.Push(&s.items, it)
+		s.index[id] = it
+	}
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Remove removes a job. Removing an unknown job does nothing.
+func (s *Scheduler) Remove(id int64) {
+	s.mu.Lock()
+	it, ok := s.index[id]
+	if ok {
+		heap.Remove(&s.items, it.index)
+		delete(s.index, id)
+	}
+	s.mu.Unlock()
+	if ok {
+		s.notify()
+	}
+}
+
+// Len returns the number of scheduled jobs.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// Next returns the earliest due time and reports whether there is any job.
+func (s *Scheduler) Next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == 0 {
+		return time.Time{}, false
+	}
+	return s.items[0].dueAt, true
+}
+
+// notify wakes up the run loop, so it can recalculate the earliest due time.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs the scheduler until the context is canceled.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		if jobs := s.popDue(); len(jobs) > 0 {
+			s.handler(s.policy.split(s.clock.Now(), jobs))
+			continue
+		}
+		var timer <-chan time.Time
+		if next, ok := s.Next(); ok {
+			timer = s.clock.After(next.Sub(s.clock.Now()))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer:
+		}
+	}
+}
+
+// popDue removes all due jobs and returns them ordered by due time.
+func (s *Scheduler) popDue() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	jobs := make([]Job, 0)
+	for len(s.items) > 0 && !s.items[0].dueAt.After(now) {
+		it := heap.Pop(&s.items).(*item)
+		delete(s.index, it.id)
+		jobs = append(jobs, Job{ID: it.id, DueAt: it.dueAt})
+	}
+	return jobs
+}
+
+type item struct {
+	id    int64
+	dueAt time.Time
+	index int
+}
+
+// itemHeap is a min-heap of items ordered by due time. It implements [heap.Interface].
+type itemHeap []*item
+
+func (h itemHeap) Len() int { return len(h) }
+
+func (h itemHeap) Less(i, j int) bool {
+	if h[i].dueAt.Equal(h[j].dueAt) {
+		return h[i].id < h[j].id
+	}
+	return h[i].dueAt.Before(h[j].dueAt)
+}
+
+func (h itemHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *itemHeap) Push(x any) {
+	it := x.(*item)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *itemHeap) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	it.index = -1
+	*h = old[:n-1]
+	return it
+}
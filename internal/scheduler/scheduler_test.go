@@ -0,0 +1,297 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/scheduler"
+)
+
+// fakeClock is a clock for tests which only moves forward when advanced.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []fakeTimer
+	started chan struct{} // receives when a timer is started
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, started: make(chan struct{}, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+	} else {
+		c.timers = append(c.timers, t)
+	}
+	c.started <- struct{}{}
+	return t.c
+}
+
+// Advance moves the clock forward and fires all timers which are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			timers = append(timers, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = timers
+}
+
+// waitForTimer waits until the scheduler has started a new timer, i.e. is sleeping.
+func (c *fakeClock) waitForTimer(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.started:
+	case <-time.After(time.Second):
+		t.Fatal("timeout while waiting for timer")
+	}
+}
+
+// startScheduler starts a new scheduler with a fake clock and
+// returns it with a channel receiving the IDs of due jobs.
+func startScheduler(t *testing.T) (*scheduler.Scheduler, *fakeClock, chan []int64) {
+	calls := make(chan []int64, 10)
+	s, clock := startSchedulerWithPolicy(t, scheduler.CatchUpPolicy{}, func(b scheduler.Batch) {
+		calls <- b.IDs()
+	})
+	return s, clock, calls
+}
+
+// startSchedulerWithPolicy starts a new scheduler with a fake clock and a catch-up policy.
+func startSchedulerWithPolicy(t *testing.T, policy scheduler.CatchUpPolicy, handler func(b scheduler.Batch)) (*scheduler.Scheduler, *fakeClock) {
+	clock := newFakeClock(time.Date(2025, 10, 17, 9, 0, 0, 0, time.UTC))
+	s := scheduler.New(clock, policy, handler)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go s.Run(ctx)
+	return s, clock
+}
+
+func receive(t *testing.T, calls chan []int64) []int64 {
+	t.Helper()
+	select {
+	case ids := <-calls:
+		return ids
+	case <-time.After(time.Second):
+		t.Fatal("timeout while waiting for handler call")
+	}
+	return nil
+}
+
+func assertNoCall(t *testing.T, calls chan []int64) {
+	t.Helper()
+	select {
+	case ids := <-calls:
+		t.Fatalf("unexpected handler call: %v", ids)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("calls handler when job is due", func(t *testing.T) {
+		s, clock, calls := startScheduler(t)
+		s.Set(1, clock.Now().Add(10*time.Second))
+		clock.waitForTimer(t)
+		clock.Advance(9 * time.Second)
+		assertNoCall(t, calls)
+		clock.Advance(time.Second)
+		assert.Equal(t, []int64{1}, receive(t, calls))
+		assert.Equal(t, 0, s.Len())
+	})
+	t.Run("calls handler immediately for past due jobs", func(t *testing.T) {
+		s, clock, calls := startScheduler(t)
+		s.Set(1, clock.Now().Add(-time.Hour))
+		assert.Equal(t, []int64{1}, receive(t, calls))
+	})
+	t.Run("wakes up when an earlier job is added", func(t *testing.T) {
+		s, clock, calls := startScheduler(t)
+		s.Set(1, clock.Now().Add(time.Hour))
+		clock.waitForTimer(t)
+		s.Set(2, clock.Now().Add(time.Minute))
+		clock.waitForTimer(t)
+		clock.Advance(time.Minute)
+		assert.Equal(t, []int64{2}, receive(t, calls))
+		assert.Equal(t, 1, s.Len())
+	})
+	t.Run("can move due time of job", func(t *testing.T) {
+		s, clock, calls := startScheduler(t)
+		s.Set(1, clock.Now().Add(time.Minute))
+		clock.waitForTimer(t)
+		s.Set(1, clock.Now().Add(time.Hour))
+		clock.waitForTimer(t)
+		clock.Advance(time.Minute)
+		assertNoCall(t, calls)
+		clock.Advance(time.Hour)
+		assert.Equal(t, []int64{1}, receive(t, calls))
+	})
+	t.Run("can remove job", func(t *testing.T) {
+		s, clock, calls := startScheduler(t)
+		s.Set(1, clock.Now().Add(time.Minute))
+		s.Set(2, clock.Now().Add(2*time.Minute))
+		s.Remove(1)
+		s.Set(2, time.Time{})
+		assert.Equal(t, 0, s.Len())
+		clock.Advance(time.Hour)
+		assertNoCall(t, calls)
+	})
+	t.Run("calls handler with all due jobs ordered by due time", func(t *testing.T) {
+		s, clock, calls := startScheduler(t)
+		s.Set(3, clock.Now().Add(3*time.Minute))
+		s.Set(1, clock.Now().Add(2*time.Minute))
+		s.Set(2, clock.Now().Add(time.Minute))
+		s.Set(4, clock.Now().Add(time.Hour))
+		clock.waitForTimer(t)
+		clock.Advance(5 * time.Minute)
+		assert.Equal(t, []int64{2, 1, 3}, receive(t, calls))
+		next, ok := s.Next()
+		if assert.True(t, ok) {
+			assert.Equal(t, clock.Now().Add(55*time.Minute), next)
+		}
+	})
+	t.Run("can schedule jobs from the handler", func(t *testing.T) {
+		clock := newFakeClock(time.Date(2025, 10, 17, 9, 0, 0, 0, time.UTC))
+		calls := make(chan []int64, 10)
+		var s *scheduler.Scheduler
+		s = scheduler.New(clock, scheduler.CatchUpPolicy{}, func(b scheduler.Batch) {
+			ids := b.IDs()
+			s.Set(ids[0], clock.Now().Add(time.Minute)) // e.g. a recurring reminder
+			calls <- ids
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.Run(ctx)
+		s.Set(1, clock.Now())
+		assert.Equal(t, []int64{1}, receive(t, calls))
+		clock.waitForTimer(t)
+		clock.Advance(time.Minute)
+		assert.Equal(t, []int64{1}, receive(t, calls))
+	})
+	t.Run("stops when context is canceled", func(t *testing.T) {
+		clock := newFakeClock(time.Now())
+		s := scheduler.New(clock, scheduler.CatchUpPolicy{}, func(b scheduler.Batch) {})
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			s.Run(ctx)
+			close(done)
+		}()
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("scheduler did not stop")
+		}
+	})
+}
+
+func TestCatchUp(t *testing.T) {
+	ids := func(jobs []scheduler.Job) []int64 {
+		r := make([]int64, 0)
+		for _, j := range jobs {
+			r = append(r, j.ID)
+		}
+		return r
+	}
+	// runAfterDowntime returns the batch for jobs which were due while the scheduler was down
+	// and one job which becomes due after it was started.
+	runAfterDowntime := func(t *testing.T, policy scheduler.CatchUpPolicy) (scheduler.Batch, scheduler.Batch) {
+		clock := newFakeClock(time.Date(2025, 10, 17, 9, 0, 0, 0, time.UTC))
+		calls := make(chan scheduler.Batch, 10)
+		s := scheduler.New(clock, policy, func(b scheduler.Batch) {
+			calls <- b
+		})
+		now := clock.Now()
+		s.Set(1, now.Add(-30*time.Hour))
+		s.Set(2, now.Add(-3*time.Hour))
+		s.Set(3, now.Add(-time.Minute))
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go s.Run(ctx)
+		first := receiveBatch(t, calls)
+		s.Set(4, now.Add(time.Hour))
+		clock.waitForTimer(t)
+		clock.Advance(time.Hour)
+		second := receiveBatch(t, calls)
+		return first, second
+	}
+	t.Run("can send all missed jobs", func(t *testing.T) {
+		first, second := runAfterDowntime(t, scheduler.CatchUpPolicy{Mode: scheduler.CatchUpSendAll})
+		assert.Equal(t, []int64{1, 2, 3}, ids(first.Send))
+		assert.Empty(t, first.Digest)
+		assert.Empty(t, first.Drop)
+		assert.Equal(t, []int64{4}, ids(second.Send))
+	})
+	t.Run("can send missed jobs as digest", func(t *testing.T) {
+		first, second := runAfterDowntime(t, scheduler.CatchUpPolicy{Mode: scheduler.CatchUpDigest})
+		assert.Equal(t, []int64{3}, ids(first.Send))
+		assert.Equal(t, []int64{1, 2}, ids(first.Digest))
+		assert.Empty(t, first.Drop)
+		assert.Equal(t, []int64{4}, ids(second.Send))
+	})
+	t.Run("can drop jobs missed too long ago", func(t *testing.T) {
+		first, second := runAfterDowntime(t, scheduler.CatchUpPolicy{Mode: scheduler.CatchUpDrop, MaxLate: 24 * time.Hour})
+		assert.Equal(t, []int64{2, 3}, ids(first.Send))
+		assert.Empty(t, first.Digest)
+		assert.Equal(t, []int64{1}, ids(first.Drop))
+		assert.Equal(t, []int64{4}, ids(second.Send))
+	})
+	t.Run("can change when jobs count as missed", func(t *testing.T) {
+		first, _ := runAfterDowntime(t, scheduler.CatchUpPolicy{Mode: scheduler.CatchUpDigest, LateAfter: 4 * time.Hour})
+		assert.Equal(t, []int64{2, 3}, ids(first.Send))
+		assert.Equal(t, []int64{1}, ids(first.Digest))
+	})
+	t.Run("reports time and due times of batch", func(t *testing.T) {
+		first, _ := runAfterDowntime(t, scheduler.CatchUpPolicy{})
+		want := time.Date(2025, 10, 17, 9, 0, 0, 0, time.UTC)
+		assert.Equal(t, want, first.Now)
+		assert.Equal(t, want.Add(-30*time.Hour), first.Send[0].DueAt)
+	})
+}
+
+func TestParseCatchUpMode(t *testing.T) {
+	for _, m := range []scheduler.CatchUpMode{scheduler.CatchUpSendAll, scheduler.CatchUpDigest, scheduler.CatchUpDrop} {
+		got, err := scheduler.ParseCatchUpMode(m.String())
+		if assert.NoError(t, err) {
+			assert.Equal(t, m, got)
+		}
+	}
+	_, err := scheduler.ParseCatchUpMode("invalid")
+	assert.Error(t, err)
+}
+
+func receiveBatch(t *testing.T, calls chan scheduler.Batch) scheduler.Batch {
+	t.Helper()
+	select {
+	case b := <-calls:
+		return b
+	case <-time.After(time.Second):
+		t.Fatal("timeout while waiting for handler call")
+	}
+	return scheduler.Batch{}
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Stats are statistics about the stored data.
+type Stats struct {
+	Users     int
+	Bookmarks int
+	Reminders int
+}
+
+// Stats returns statistics about the stored data.
+func (st *Storage) Stats() (Stats, error) {
+	ctx := context.Background()
+	var s Stats
+	for _, x := range []struct {
+		v *int
+		f func(context.Context) (int64, error)
+	}{
+		{&s.Users, st.qRO.CountUsers},
+		{&s.Bookmarks, st.qRO.CountAllBookmarks},
+		{&s.Reminders, st.qRO.CountAllReminders},
+	} {
+		n, err := x.f(ctx)
+		if err != nil {
+			return Stats{}, fmt.Errorf("Stats: %w", err)
+		}
+		*x.v = int(n)
+	}
+	return s, nil
+}
+
+// DeleteUser deletes all data of a user in one transaction
+// and returns how many bookmarks were deleted.
+func (st *Storage) DeleteUser(userID string) (int, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("DeleteUser: %s: %w", userID, err)
+	}
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	// deliveries and tags of bookmarks are deleted by cascade
+	ids, err := qtx.DeleteBookmarksForUser(ctx, userID)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+	for _, f := range []func(context.Context, string) error{
+		qtx.DeleteTagsForUser,
+		qtx.DeletePendingBookmarksForUser,
+		qtx.DeleteUserSettings,
+	} {
+		if err := f(ctx, userID); err != nil {
+			return 0, wrapErr(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, wrapErr(err)
+	}
+	for _, id := range ids {
+		st.notifyDueAtChanged(id, time.Time{})
+	}
+	slog.Info("User deleted", "user", userID, "bookmarks", len(ids))
+	return len(ids), nil
+}
+
+// Vacuum rebuilds the database file to reclaim unused space.
+func (st *Storage) Vacuum() error {
+	if _, err := st.dbRW.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("Vacuum: %w", err)
+	}
+	slog.Info("Database vacuumed")
+	return nil
+}
+
+// IntegrityCheck checks the integrity of the database and returns the problems found.
+func (st *Storage) IntegrityCheck() ([]string, error) {
+	problems, err := integrityCheck(st.dbRW)
+	if err != nil {
+		return nil, fmt.Errorf("IntegrityCheck: %w", err)
+	}
+	return problems, nil
+}
+
+func integrityCheck(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		if s != "ok" {
+			problems = append(problems, s)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
@@ -0,0 +1,112 @@
+package storage_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestStats(t *testing.T) {
+	st := NewTestStorage(t)
+	CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user-1", DueAt: time.Now().Add(time.Hour)})
+	CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user-1"})
+	CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user-2"})
+	if err := st.UpdateOrCreateUserSettings(storage.DefaultUserSettings("user-3")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := st.Stats()
+	if assert.NoError(t, err) {
+		assert.Equal(t, storage.Stats{Users: 3, Bookmarks: 3, Reminders: 1}, got)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	db := NewTestDB(t)
+	st := storage.New(db, db)
+	t.Run("can delete all data of a user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user", DueAt: time.Now().Add(time.Hour)})
+		CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		if err := st.AddTag(bm.ID, "user", "work"); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.SetNote(bm.ID, "user", "note"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.RecordDeliveryFailure(bm.ID, bm.DueAt.Time, errors.New("failed"), true); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.UpdateOrCreateUserSettings(storage.DefaultUserSettings("user")); err != nil {
+			t.Fatal(err)
+		}
+		pendingID, err := st.CreatePendingBookmark(storage.UpdateOrCreateBookmarkParams{
+			ChannelID: "channel",
+			MessageID: "message",
+			Timestamp: time.Now(),
+			UserID:    "user",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		other := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "other"})
+		var changes []int64
+		st.OnDueAtChanged(func(id int64, dueAt time.Time) {
+			if dueAt.IsZero() {
+				changes = append(changes, id)
+			}
+		})
+		defer st.OnDueAtChanged(nil)
+		n, err := st.DeleteUser("user")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 2, n)
+		assert.Len(t, changes, 2)
+		c, err := st.CountBookmarksForUser("user")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, c)
+		}
+		tags, err := st.ListTagsForUser("user")
+		if assert.NoError(t, err) {
+			assert.Empty(t, tags)
+		}
+		stats, err := st.Stats()
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, stats.Users) // settings are gone, too
+		}
+		var deliveries int
+		if err := db.QueryRow("SELECT COUNT(*) FROM reminder_deliveries").Scan(&deliveries); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 0, deliveries)
+		_, err = st.GetPendingBookmark(pendingID, "user")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = st.GetBookmark(other.ID)
+		assert.NoError(t, err)
+	})
+	t.Run("does nothing when user has no data", func(t *testing.T) {
+		n, err := st.DeleteUser("unknown")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, n)
+		}
+	})
+}
+
+func TestDatabaseMaintenance(t *testing.T) {
+	st, _, _ := newFileTestStorage(t, t.TempDir())
+	CreateBookmark(t, st)
+	t.Run("can check integrity", func(t *testing.T) {
+		got, err := st.IntegrityCheck()
+		if assert.NoError(t, err) {
+			assert.Empty(t, got)
+		}
+	})
+	t.Run("can vacuum", func(t *testing.T) {
+		assert.NoError(t, st.Vacuum())
+	})
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Naming of the files created by [RotateBackups], e.g. "bookmarker-backup-20250117.sqlite"
+const (
+	backupPrefix     = "bookmarker-backup-"
+	backupDateLayout = "20060102"
+	backupSuffix     = ".sqlite"
+)
+
+// Backup writes a copy of the database to a new file at path.
+// It uses SQLite's online backup API and is safe to use while the database is in use.
+// The copy is written to a temporary file first, so path only exists when the backup is complete.
+func Backup(ctx context.Context, db *sql.DB, path string) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("Backup: %s: %w", path, err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return wrapErr(os.ErrExist)
+	}
+	tmp := path + ".tmp"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return wrapErr(err)
+	}
+	if err := backupToFile(ctx, db, tmp); err != nil {
+		os.Remove(tmp)
+		return wrapErr(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return wrapErr(err)
+	}
+	slog.Info("Database backup created", "path", path)
+	return nil
+}
+
+func backupToFile(ctx context.Context, db *sql.DB, path string) error {
+	dest, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	err = destConn.Raw(func(destDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			d, ok1 := destDriverConn.(*sqlite3.SQLiteConn)
+			s, ok2 := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok1 || !ok2 {
+				return fmt.Errorf("not a SQLite connection")
+			}
+			b, err := d.Backup("main", s, "main")
+			if err != nil {
+				return err
+			}
+			// copy all pages in one step, so the copy is a consistent snapshot
+			if _, err := b.Step(-1); err != nil {
+				b.Finish()
+				return err
+			}
+			return b.Finish()
+		})
+	})
+	if err != nil {
+		return err
+	}
+	// the copy inherits WAL mode, but a backup should be a single file
+	_, err = destConn.ExecContext(ctx, "PRAGMA journal_mode=DELETE")
+	return err
+}
+
+// RotateBackups creates the daily backup of the database in dir unless it already exists
+// and deletes the oldest backups, so that at most keep backups remain.
+// It returns the path of the backup created or an empty string when there was none.
+func RotateBackups(ctx context.Context, db *sql.DB, dir string, keep int, now time.Time) (string, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("RotateBackups: %s: %w", dir, err)
+	}
+	if keep < 1 {
+		return "", wrapErr(fmt.Errorf("invalid keep: %d", keep))
+	}
+	var created string
+	path := filepath.Join(dir, backupPrefix+now.Format(backupDateLayout)+backupSuffix)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := Backup(ctx, db, path); err != nil {
+			return "", err
+		}
+		created = path
+	} else if err != nil {
+		return "", wrapErr(err)
+	}
+	backups, err := listBackups(dir)
+	if err != nil {
+		return created, wrapErr(err)
+	}
+	for len(backups) > keep {
+		if err := os.Remove(backups[0]); err != nil {
+			return created, wrapErr(err)
+		}
+		slog.Info("Old database backup deleted", "path", backups[0])
+		backups = backups[1:]
+	}
+	return created, nil
+}
+
+// listBackups returns the paths of the backups in dir from oldest to newest.
+func listBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		date := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix)
+		if _, err := time.Parse(backupDateLayout, date); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	slices.Sort(backups) // the dates sort chronologically
+	return backups, nil
+}
+
+// Restore replaces the database at dbPath with the backup at src.
+// The database must not be in use. The replaced files are kept with the suffix ".pre-restore".
+//
+// The backup must pass an integrity check and have a schema version known to this build.
+// Backups with older versions are upgraded by [Migrate] when the database is opened next.
+// Returns the schema version of the backup.
+func Restore(src, dbPath string) (int, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("Restore: %s: %w", src, err)
+	}
+	v, err := validateBackup(src)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+	tmp := dbPath + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return 0, wrapErr(err)
+	}
+	pre := dbPath + ".pre-restore"
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		err := os.Rename(dbPath+suffix, pre+suffix)
+		if errors.Is(err, os.ErrNotExist) {
+			os.Remove(pre + suffix) // must not be mixed with the files of another database
+		} else if err != nil {
+			os.Remove(tmp)
+			return 0, wrapErr(err)
+		}
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return 0, wrapErr(err)
+	}
+	slog.Info("Database restored", "src", src, "version", v)
+	return v, nil
+}
+
+// validateBackup checks whether a file is a valid backup and returns its schema version.
+func validateBackup(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	db, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	problems, err := integrityCheck(db)
+	if err != nil {
+		return 0, fmt.Errorf("integrity check: %w", err)
+	}
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("integrity check: %s", strings.Join(problems, "; "))
+	}
+	v, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	if v == 0 {
+		return 0, fmt.Errorf("not a database with migrations")
+	}
+	if latest := LatestSchemaVersion(); v > latest {
+		return 0, fmt.Errorf("schema version %d is newer than %d", v, latest)
+	}
+	return v, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
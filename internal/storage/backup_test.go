@@ -0,0 +1,154 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestBackup(t *testing.T) {
+	ctx := context.Background()
+	t.Run("can create backup of database in use", func(t *testing.T) {
+		dir := t.TempDir()
+		st, dbRW, dbRO := newFileTestStorage(t, dir)
+		bm := CreateBookmark(t, st)
+		path := filepath.Join(dir, "backup.sqlite")
+		err := storage.Backup(ctx, dbRO, path)
+		if !assert.NoError(t, err) {
+			return
+		}
+		CreateBookmark(t, st) // not in the backup
+		dbRW.Close()
+		dbRO.Close()
+		st2, _, _ := openFileTestStorage(t, path)
+		got, err := st2.GetBookmark(bm.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, bm.Content, got.Content)
+		}
+		n, err := st2.CountBookmarksForUser(bm.UserID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+		}
+	})
+	t.Run("should not overwrite existing file", func(t *testing.T) {
+		dir := t.TempDir()
+		_, _, dbRO := newFileTestStorage(t, dir)
+		path := filepath.Join(dir, "backup.sqlite")
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err := storage.Backup(ctx, dbRO, path)
+		assert.ErrorIs(t, err, os.ErrExist)
+	})
+}
+
+func TestRotateBackups(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	_, _, dbRO := newFileTestStorage(t, dir)
+	day := time.Date(2025, 1, 17, 3, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		_, err := storage.RotateBackups(ctx, dbRO, dir, 2, day.AddDate(0, 0, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Run("should keep the newest backups", func(t *testing.T) {
+		got, err := filepath.Glob(filepath.Join(dir, "bookmarker-backup-*"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{
+				filepath.Join(dir, "bookmarker-backup-20250119.sqlite"),
+				filepath.Join(dir, "bookmarker-backup-20250120.sqlite"),
+			}, got)
+		}
+	})
+	t.Run("should create only one backup per day", func(t *testing.T) {
+		got, err := storage.RotateBackups(ctx, dbRO, dir, 2, day.AddDate(0, 0, 3).Add(time.Hour))
+		if assert.NoError(t, err) {
+			assert.Empty(t, got)
+		}
+	})
+}
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	t.Run("can restore backup", func(t *testing.T) {
+		dir := t.TempDir()
+		st, dbRW, dbRO := newFileTestStorage(t, dir)
+		bm := CreateBookmark(t, st)
+		backup := filepath.Join(t.TempDir(), "backup.sqlite")
+		if err := storage.Backup(ctx, dbRO, backup); err != nil {
+			t.Fatal(err)
+		}
+		CreateBookmark(t, st) // not in the backup
+		dbRW.Close()
+		dbRO.Close()
+		dbPath := filepath.Join(dir, "bookmarker.sqlite")
+		v, err := storage.Restore(backup, dbPath)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, storage.LatestSchemaVersion(), v)
+		assert.FileExists(t, dbPath+".pre-restore")
+		st2, _, _ := newFileTestStorage(t, dir)
+		n, err := st2.CountBookmarksForUser(bm.UserID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+		}
+	})
+	t.Run("should reject file which is not a database", func(t *testing.T) {
+		dir := t.TempDir()
+		backup := filepath.Join(dir, "backup.sqlite")
+		if err := os.WriteFile(backup, []byte("no database"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		dbPath := filepath.Join(dir, "bookmarker.sqlite")
+		_, err := storage.Restore(backup, dbPath)
+		assert.Error(t, err)
+		assert.NoFileExists(t, dbPath)
+	})
+	t.Run("should reject backup with newer schema version", func(t *testing.T) {
+		dir := t.TempDir()
+		_, dbRW, dbRO := newFileTestStorage(t, dir)
+		_, err := dbRW.Exec(
+			"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'future', ?)",
+			storage.LatestSchemaVersion()+1, time.Now(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backup := filepath.Join(t.TempDir(), "backup.sqlite")
+		if err := storage.Backup(ctx, dbRO, backup); err != nil {
+			t.Fatal(err)
+		}
+		dbPath := filepath.Join(t.TempDir(), "bookmarker.sqlite")
+		_, err = storage.Restore(backup, dbPath)
+		assert.Error(t, err)
+		assert.NoFileExists(t, dbPath)
+	})
+}
+
+// newFileTestStorage returns a new storage for a database file in dir.
+func newFileTestStorage(t *testing.T, dir string) (*storage.Storage, *sql.DB, *sql.DB) {
+	return openFileTestStorage(t, filepath.Join(dir, "bookmarker.sqlite"))
+}
+
+// openFileTestStorage returns a new storage for the database file at path.
+func openFileTestStorage(t *testing.T, path string) (*storage.Storage, *sql.DB, *sql.DB) {
+	dbRW, dbRO, err := storage.InitDB("file:///" + filepath.ToSlash(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		dbRW.Close()
+		dbRO.Close()
+	})
+	return storage.New(dbRW, dbRO), dbRW, dbRO
+}
@@ -3,13 +3,19 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"example/discord-bookmarker/internal/queries"
 )
 
+// MaxNoteLength is the max number of characters of a note.
+const MaxNoteLength = 1000
+
 func (st *Storage) CountBookmarksForUser(userID string) (int, error) {
 	x, err := st.qRO.CountBookmarks(context.Background(), userID)
 	if err != nil {
@@ -18,12 +24,37 @@ func (st *Storage) CountBookmarksForUser(userID string) (int, error) {
 	return int(x), nil
 }
 
-func (st *Storage) DeleteBookmark(id int64) error {
-	err := st.qRW.DeleteBookmark(context.Background(), id)
-	if err != nil {
+// DeleteBookmark deletes a bookmark owned by a user and the tags no longer used by any bookmark.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) DeleteBookmark(id int64, userID string) error {
+	wrapErr := func(err error) error {
 		return fmt.Errorf("DeleteBookmark: ID %d: %w", id, err)
 	}
-	slog.Info("Bookmark deleted", "id", id)
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	n, err := qtx.DeleteBookmark(ctx, queries.DeleteBookmarkParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	if n == 0 {
+		return wrapErr(sql.ErrNoRows)
+	}
+	if err := qtx.DeleteUnusedTags(ctx, userID); err != nil {
+		return wrapErr(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return wrapErr(err)
+	}
+	slog.Info("Bookmark deleted", "id", id, "user", userID)
+	st.notifyDueAtChanged(id, time.Time{})
 	return nil
 }
 
@@ -44,26 +75,213 @@ func (st *Storage) GetBookmark(id int64) (queries.Bookmark, error) {
 	return o, nil
 }
 
-func (st *Storage) RemoveReminder(id int64) error {
-	err := st.qRW.UpdateBookmarkDueAt(context.Background(), queries.UpdateBookmarkDueAtParams{
-		ID: id,
+// GetBookmarkForUser returns a bookmark owned by a user.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) GetBookmarkForUser(id int64, userID string) (queries.Bookmark, error) {
+	o, err := st.qRO.GetBookmarkForUser(context.Background(), queries.GetBookmarkForUserParams{
+		ID:     id,
+		UserID: userID,
 	})
+	if err != nil {
+		return queries.Bookmark{}, err
+	}
+	return o, nil
+}
+
+// RemoveReminder removes the reminder including any recurrence from a bookmark.
+func (st *Storage) RemoveReminder(id int64) error {
+	err := st.qRW.ClearBookmarkReminder(context.Background(), id)
 	if err != nil {
 		return fmt.Errorf("RemoveReminder: ID %d: %w", id, err)
 	}
 	slog.Info("Reminder removed", "id", id)
+	st.notifyDueAtChanged(id, time.Time{})
 	return nil
 }
 
-func (st *Storage) SetReminder(id int64, dueAt time.Time) error {
-	err := st.qRW.UpdateBookmarkDueAt(context.Background(), queries.UpdateBookmarkDueAtParams{
-		ID:    id,
-		DueAt: newNullTimeFromTime(dueAt),
+// CompleteReminder completes a delivered reminder.
+// For recurring reminders it sets the next occurrence in the user's timezone and returns it.
+// Otherwise it removes the reminder and returns a zero time.
+func (st *Storage) CompleteReminder(id int64) (time.Time, error) {
+	return st.completeReminder(id, "")
+}
+
+// MarkReminderDone completes the reminder of a bookmark owned by a user like [Storage.CompleteReminder].
+// This also ends a snooze.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) MarkReminderDone(id int64, userID string) (time.Time, error) {
+	return st.completeReminder(id, userID)
+}
+
+// completeReminder completes a reminder.
+// The bookmark must be owned by the user unless userID is empty.
+func (st *Storage) completeReminder(id int64, userID string) (time.Time, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("completeReminder: ID %d: %w", id, err)
+	}
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return time.Time{}, wrapErr(err)
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	bm, err := qtx.GetBookmark(ctx, id)
+	if err != nil {
+		return time.Time{}, wrapErr(err)
+	}
+	if userID != "" && bm.UserID != userID {
+		return time.Time{}, wrapErr(sql.ErrNoRows)
+	}
+	var next time.Time
+	if bm.Recurrence != "" && bm.DueAt.Valid {
+		rule, err := ParseRecurrenceRule(bm.Recurrence)
+		if err != nil {
+			return time.Time{}, wrapErr(err)
+		}
+		loc := time.UTC
+		o, err := qtx.GetUserSettings(ctx, bm.UserID)
+		if err == nil {
+			loc = UserSettings{Timezone: o.Timezone}.Location()
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, wrapErr(err)
+		}
+		// occurrences are calculated from the anchor, so snoozing does not shift them
+		prev := bm.DueAt.Time
+		if bm.RecurrenceAnchor.Valid {
+			prev = bm.RecurrenceAnchor.Time
+		}
+		next, err = rule.Next(prev, time.Now(), loc)
+		if err != nil {
+			return time.Time{}, wrapErr(err)
+		}
+		next = next.UTC()
+		err = qtx.UpdateBookmarkDueAt(ctx, queries.UpdateBookmarkDueAtParams{
+			ID:    id,
+			DueAt: newNullTimeFromTime(next),
+		})
+		if err != nil {
+			return time.Time{}, wrapErr(err)
+		}
+	} else {
+		if err := qtx.ClearBookmarkReminder(ctx, id); err != nil {
+			return time.Time{}, wrapErr(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, wrapErr(err)
+	}
+	if next.IsZero() {
+		slog.Info("Reminder completed", "id", id)
+	} else {
+		slog.Info("Reminder rescheduled", "id", id, "dueAt", next)
+	}
+	st.notifyDueAtChanged(id, next)
+	return next, nil
+}
+
+// SnoozeReminder postpones the reminder of a bookmark owned by a user until a time.
+// Occurrences of recurring reminders are not shifted by snoozing.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) SnoozeReminder(id int64, userID string, until time.Time) error {
+	n, err := st.qRW.SnoozeBookmarkForUser(context.Background(), queries.SnoozeBookmarkForUserParams{
+		ID:     id,
+		DueAt:  newNullTimeFromTime(until),
+		UserID: userID,
 	})
+	if err != nil {
+		return fmt.Errorf("SnoozeReminder: ID %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("SnoozeReminder: ID %d: %w", id, sql.ErrNoRows)
+	}
+	slog.Info("Reminder snoozed", "id", id, "user", userID, "until", until)
+	st.notifyDueAtChanged(id, until)
+	return nil
+}
+
+// SetRecurrence sets the recurrence rule for the reminder of a bookmark owned by a user.
+// An empty rule removes the recurrence.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) SetRecurrence(id int64, userID string, rule string) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("SetRecurrence: ID %d: %w", id, err)
+	}
+	if rule != "" {
+		r, err := ParseRecurrenceRule(rule)
+		if err != nil {
+			return wrapErr(err)
+		}
+		rule = r.String()
+	}
+	n, err := st.qRW.UpdateBookmarkRecurrenceForUser(context.Background(), queries.UpdateBookmarkRecurrenceForUserParams{
+		ID:         id,
+		Recurrence: rule,
+		UserID:     userID,
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	if n == 0 {
+		return wrapErr(sql.ErrNoRows)
+	}
+	slog.Info("Recurrence set", "id", id, "user", userID, "rule", rule)
+	return nil
+}
+
+// SetNote sets the personal note for a bookmark owned by a user.
+// An empty note removes it.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) SetNote(id int64, userID string, note string) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("SetNote: ID %d: %w", id, err)
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MaxNoteLength {
+		return wrapErr(fmt.Errorf("note longer than %d characters", MaxNoteLength))
+	}
+	n, err := st.qRW.UpdateBookmarkNoteForUser(context.Background(), queries.UpdateBookmarkNoteForUserParams{
+		ID:        id,
+		Note:      sql.NullString{String: note, Valid: note != ""},
+		UpdatedAt: time.Now().UTC(),
+		UserID:    userID,
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	if n == 0 {
+		return wrapErr(sql.ErrNoRows)
+	}
+	slog.Info("Note set", "id", id, "user", userID)
+	return nil
+}
+
+// SetReminder sets the reminder for a bookmark owned by a user.
+// A zero dueAt removes the reminder including any recurrence.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) SetReminder(id int64, userID string, dueAt time.Time) error {
+	var n int64
+	var err error
+	if dueAt.IsZero() {
+		n, err = st.qRW.ClearBookmarkReminderForUser(context.Background(), queries.ClearBookmarkReminderForUserParams{
+			ID:     id,
+			UserID: userID,
+		})
+	} else {
+		n, err = st.qRW.UpdateBookmarkDueAtForUser(context.Background(), queries.UpdateBookmarkDueAtForUserParams{
+			ID:     id,
+			DueAt:  newNullTimeFromTime(dueAt),
+			UserID: userID,
+		})
+	}
 	if err != nil {
 		return fmt.Errorf("SetReminder: ID %d: %w", id, err)
 	}
-	slog.Info("Reminder set", "id", id)
+	if n == 0 {
+		return fmt.Errorf("SetReminder: ID %d: %w", id, sql.ErrNoRows)
+	}
+	slog.Info("Reminder set", "id", id, "user", userID)
+	st.notifyDueAtChanged(id, dueAt)
 	return nil
 }
 
@@ -71,10 +289,60 @@ func (st *Storage) ListBookmarksForUser(userID string) ([]queries.Bookmark, erro
 	return st.qRO.ListBookmarksForUser(context.Background(), userID)
 }
 
+// ListBookmarksMatchingForUser returns up to limit bookmarks of a user
+// whose ID starts with input or whose content contains input, ignoring case.
+// All bookmarks match an empty input.
+func (st *Storage) ListBookmarksMatchingForUser(userID string, input string, limit int) ([]queries.Bookmark, error) {
+	bookmarks, err := st.qRO.ListBookmarksMatchingForUser(context.Background(), queries.ListBookmarksMatchingForUserParams{
+		UserID: userID,
+		Input:  input,
+		Limit:  int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ListBookmarksMatchingForUser: %s: %q: %w", userID, input, err)
+	}
+	return bookmarks, nil
+}
+
+// ListDueBookmarks returns the bookmarks with due reminders.
+// Reminders are excluded while waiting for the next attempt to send them,
+// while claimed by a worker and after they have been sent or failed for good.
 func (st *Storage) ListDueBookmarks() ([]queries.Bookmark, error) {
 	return st.qRO.ListDueBookmarks(context.Background(), newNullTimeFromTime(time.Now().UTC()))
 }
 
+// ListReminders returns when each reminder should be sent next by bookmark ID.
+// That is the due time, the time of the next attempt when sending it failed or was deferred before
+// or when the lease expires for a reminder which is being sent.
+// Reminders which have been sent or failed for good are not included.
+func (st *Storage) ListReminders() (map[int64]time.Time, error) {
+	rows, err := st.qRO.ListReminders(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("ListReminders: %w", err)
+	}
+	m := make(map[int64]time.Time, len(rows))
+	for _, r := range rows {
+		if t, ok := nextReminderTime(r); ok {
+			m[r.ID] = t
+		}
+	}
+	return m, nil
+}
+
+// nextReminderTime returns when a reminder should be sent next
+// and reports whether it should be sent at all.
+func nextReminderTime(r queries.ListRemindersRow) (time.Time, bool) {
+	switch r.Status.String {
+	case "":
+		return r.DueAt.Time, true
+	case DeliveryDeferred, DeliveryPending:
+		return r.NextAttemptAt.Time, true
+	case DeliverySending:
+		return r.LeaseExpiresAt.Time, true
+	}
+	return time.Time{}, false
+}
+
 type UpdateOrCreateBookmarkParams struct {
 	AuthorID  string
 	ChannelID string
@@ -111,7 +379,7 @@ func (st *Storage) UpdateOrCreateBookmark(arg UpdateOrCreateBookmarkParams) (int
 	if err != nil {
 		return 0, false, wrapErr(err)
 	}
-	id, err := qtx.UpdateOrCreateBookmark(ctx, queries.UpdateOrCreateBookmarkParams{
+	err = qtx.UpdateOrCreateBookmark(ctx, queries.UpdateOrCreateBookmarkParams{
 		AuthorID:  arg.AuthorID,
 		ChannelID: arg.ChannelID,
 		Content:   arg.Content,
@@ -119,13 +387,23 @@ func (st *Storage) UpdateOrCreateBookmark(arg UpdateOrCreateBookmarkParams) (int
 		DueAt:     newNullTimeFromTime(arg.DueAt),
 		GuildID:   arg.GuildID,
 		MessageID: arg.MessageID,
-		Timestamp: arg.Timestamp,
+		Timestamp: arg.Timestamp.UTC(),
 		UpdatedAt: time.Now().UTC(),
 		UserID:    arg.UserID,
 	})
 	if err != nil {
 		return 0, false, wrapErr(err)
 	}
+	// the last insert ID is not set when an existing bookmark is updated
+	id, err := qtx.GetBookmarkIDForMessage(ctx, queries.GetBookmarkIDForMessageParams{
+		ChannelID: arg.ChannelID,
+		GuildID:   arg.GuildID,
+		MessageID: arg.MessageID,
+		UserID:    arg.UserID,
+	})
+	if err != nil {
+		return 0, false, wrapErr(err)
+	}
 	c2, err := qtx.CountBookmarks(ctx, arg.UserID)
 	if err != nil {
 		return 0, false, wrapErr(err)
@@ -135,6 +413,7 @@ func (st *Storage) UpdateOrCreateBookmark(arg UpdateOrCreateBookmarkParams) (int
 	}
 	created := c2 > c1
 	slog.Info("Updated bookmark", "id", id, "created", created, "user", arg.UserID)
+	st.notifyDueAtChanged(id, arg.DueAt)
 	return id, created, nil
 }
 
@@ -3,6 +3,7 @@ package storage_test
 import (
 	"database/sql"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -63,6 +64,34 @@ func TestBookmark(t *testing.T) {
 			}
 		}
 	})
+	t.Run("returns ID of updated bookmark", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		CreateBookmark(t, st)
+		id, created, err := st.UpdateOrCreateBookmark(storage.UpdateOrCreateBookmarkParams{
+			ChannelID: bm.ChannelID,
+			GuildID:   bm.GuildID,
+			MessageID: bm.MessageID,
+			UserID:    bm.UserID,
+			Timestamp: time.Now(),
+		})
+		if assert.NoError(t, err) {
+			assert.False(t, created)
+			assert.Equal(t, bm.ID, id)
+		}
+	})
+	t.Run("can list reminders", func(t *testing.T) {
+		ClearStorage(t, st)
+		dueAt := time.Now().UTC().Add(time.Hour)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{DueAt: dueAt})
+		CreateBookmark(t, st)
+		got, err := st.ListReminders()
+		if assert.NoError(t, err) {
+			if assert.Len(t, got, 1) {
+				assert.True(t, dueAt.Equal(got[bm.ID]))
+			}
+		}
+	})
 	t.Run("can list bookmarks for user", func(t *testing.T) {
 		ClearStorage(t, st)
 		userID := "abc123"
@@ -118,20 +147,43 @@ func TestBookmark(t *testing.T) {
 			assert.Equal(t, 2, got)
 		}
 	})
+	t.Run("can get bookmark for user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st)
+		bm2, err := st.GetBookmarkForUser(bm1.ID, bm1.UserID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, bm1.ID, bm2.ID)
+		}
+	})
+	t.Run("can not get bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		_, err := st.GetBookmarkForUser(bm.ID, "other")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
 	t.Run("can delete bookmarks", func(t *testing.T) {
 		ClearStorage(t, st)
 		bm := CreateBookmark(t, st)
-		err := st.DeleteBookmark(bm.ID)
+		err := st.DeleteBookmark(bm.ID, bm.UserID)
 		if assert.NoError(t, err) {
 			_, err := st.GetBookmark(bm.ID)
 			assert.ErrorIs(t, err, sql.ErrNoRows)
 		}
 	})
+	t.Run("can not delete bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.DeleteBookmark(bm.ID, "other")
+		if assert.ErrorIs(t, err, sql.ErrNoRows) {
+			_, err := st.GetBookmark(bm.ID)
+			assert.NoError(t, err)
+		}
+	})
 	t.Run("can set reminder", func(t *testing.T) {
 		ClearStorage(t, st)
 		bm := CreateBookmark(t, st)
 		dueAt := time.Now().Add(3 * time.Hour)
-		err := st.SetReminder(bm.ID, dueAt)
+		err := st.SetReminder(bm.ID, bm.UserID, dueAt)
 		if assert.NoError(t, err) {
 			bm, err := st.GetBookmark(bm.ID)
 			if assert.NoError(t, err) {
@@ -140,6 +192,207 @@ func TestBookmark(t *testing.T) {
 			}
 		}
 	})
+	t.Run("can not set reminder for bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.SetReminder(bm.ID, "other", time.Now().Add(3*time.Hour))
+		if assert.ErrorIs(t, err, sql.ErrNoRows) {
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.False(t, bm.DueAt.Valid)
+			}
+		}
+	})
+	t.Run("can set recurrence", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			DueAt: time.Now().Add(3 * time.Hour),
+		})
+		err := st.SetRecurrence(bm.ID, bm.UserID, "weekly:we,mo")
+		if assert.NoError(t, err) {
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", bm.Recurrence)
+			}
+		}
+	})
+	t.Run("should reject invalid recurrence", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.SetRecurrence(bm.ID, bm.UserID, "hourly")
+		assert.Error(t, err)
+	})
+	t.Run("can not set recurrence for bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.SetRecurrence(bm.ID, "other", "daily")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+	t.Run("removing reminder also removes recurrence", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			DueAt: time.Now().Add(3 * time.Hour),
+		})
+		if err := st.SetRecurrence(bm.ID, bm.UserID, "daily"); err != nil {
+			t.Fatal(err)
+		}
+		err := st.SetReminder(bm.ID, bm.UserID, time.Time{})
+		if assert.NoError(t, err) {
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.False(t, bm.DueAt.Valid)
+				assert.Equal(t, "", bm.Recurrence)
+			}
+		}
+	})
+	t.Run("completing a reminder removes it", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			DueAt: time.Now().Add(-1 * time.Minute),
+		})
+		next, err := st.CompleteReminder(bm.ID)
+		if assert.NoError(t, err) {
+			assert.True(t, next.IsZero())
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.False(t, bm.DueAt.Valid)
+			}
+		}
+	})
+	t.Run("completing a recurring reminder sets next occurrence in user's timezone", func(t *testing.T) {
+		ClearStorage(t, st)
+		loc, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Fatal(err)
+		}
+		userID := "abc123"
+		us := storage.DefaultUserSettings(userID)
+		us.Timezone = loc.String()
+		if err := st.UpdateOrCreateUserSettings(us); err != nil {
+			t.Fatal(err)
+		}
+		// due at 09:00 New York time before the DST change in November
+		dueAt := time.Date(2025, 10, 30, 9, 0, 0, 0, loc)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			DueAt:  dueAt,
+			UserID: userID,
+		})
+		if err := st.SetRecurrence(bm.ID, bm.UserID, "daily"); err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		next, err := st.CompleteReminder(bm.ID)
+		if assert.NoError(t, err) {
+			assert.True(t, next.After(now))
+			assert.True(t, next.Before(now.Add(25*time.Hour)))
+			assert.Equal(t, 9, next.In(loc).Hour())
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.True(t, bm.DueAt.Valid)
+				assert.True(t, next.Equal(bm.DueAt.Time))
+				assert.Equal(t, "FREQ=DAILY", bm.Recurrence)
+			}
+		}
+	})
+	t.Run("can snooze reminder", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		until := time.Now().Add(10 * time.Minute)
+		err := st.SnoozeReminder(bm.ID, bm.UserID, until)
+		if assert.NoError(t, err) {
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.True(t, until.Equal(bm.DueAt.Time))
+			}
+		}
+	})
+	t.Run("can not snooze reminder for bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.SnoozeReminder(bm.ID, "other", time.Now().Add(10*time.Minute))
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+	t.Run("snoozing does not shift occurrences of recurring reminders", func(t *testing.T) {
+		ClearStorage(t, st)
+		dueAt := time.Now().UTC().Add(-1 * time.Hour).Truncate(time.Second)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			DueAt: dueAt,
+		})
+		if err := st.SetRecurrence(bm.ID, bm.UserID, "daily"); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.SnoozeReminder(bm.ID, bm.UserID, time.Now().Add(-10*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		next, err := st.CompleteReminder(bm.ID)
+		if assert.NoError(t, err) {
+			assert.True(t, dueAt.Add(24*time.Hour).Equal(next), "want: %s, got: %s", dueAt.Add(24*time.Hour), next)
+		}
+	})
+	t.Run("can mark reminder as done", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			DueAt: time.Now().Add(10 * time.Minute),
+		})
+		next, err := st.MarkReminderDone(bm.ID, bm.UserID)
+		if assert.NoError(t, err) {
+			assert.True(t, next.IsZero())
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.False(t, bm.DueAt.Valid)
+			}
+		}
+	})
+	t.Run("can not mark reminder as done for bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			DueAt: time.Now().Add(10 * time.Minute),
+		})
+		_, err := st.MarkReminderDone(bm.ID, "other")
+		if assert.ErrorIs(t, err, sql.ErrNoRows) {
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.True(t, bm.DueAt.Valid)
+			}
+		}
+	})
+	t.Run("can set note", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.SetNote(bm.ID, bm.UserID, " Read before the meeting ")
+		if assert.NoError(t, err) {
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, sql.NullString{String: "Read before the meeting", Valid: true}, bm.Note)
+			}
+		}
+	})
+	t.Run("can remove note", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		if err := st.SetNote(bm.ID, bm.UserID, "note"); err != nil {
+			t.Fatal(err)
+		}
+		err := st.SetNote(bm.ID, bm.UserID, "")
+		if assert.NoError(t, err) {
+			bm, err := st.GetBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.False(t, bm.Note.Valid)
+			}
+		}
+	})
+	t.Run("should not set note for bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.SetNote(bm.ID, "other-user", "note")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+	t.Run("should not set note that is too long", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.SetNote(bm.ID, bm.UserID, strings.Repeat("x", storage.MaxNoteLength+1))
+		assert.Error(t, err)
+	})
 	t.Run("can remove reminder", func(t *testing.T) {
 		ClearStorage(t, st)
 		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
@@ -155,15 +408,116 @@ func TestBookmark(t *testing.T) {
 	})
 }
 
+func TestListBookmarksMatchingForUser(t *testing.T) {
+	st := NewTestStorage(t)
+	bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+		UserID:  "user",
+		Content: "Meeting notes for the release on Friday",
+	})
+	bm2 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+		UserID:  "user",
+		Content: "Lunch menu 100% vegan_food",
+	})
+	CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+		UserID:  "other",
+		Content: "Release plan",
+	})
+	ids := func(bookmarks []queries.Bookmark) []int64 {
+		ids := make([]int64, 0)
+		for _, bm := range bookmarks {
+			ids = append(ids, bm.ID)
+		}
+		return ids
+	}
+	for _, tc := range []struct {
+		name  string
+		input string
+		limit int
+		want  []int64
+	}{
+		{"empty input matches all", "", 25, []int64{bm1.ID, bm2.ID}},
+		{"content ignoring case", "FRIDAY", 25, []int64{bm1.ID}},
+		{"content at the end", "on friday", 25, []int64{bm1.ID}},
+		{"ID prefix", strconv.FormatInt(bm2.ID, 10), 25, []int64{bm2.ID}},
+		{"wildcards are literal", "100%", 25, []int64{bm2.ID}},
+		{"underscore is literal", "h_m", 25, []int64{}},
+		{"no match", "xyz", 25, []int64{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := st.ListBookmarksMatchingForUser("user", tc.input, tc.limit)
+			if assert.NoError(t, err) {
+				assert.ElementsMatch(t, tc.want, ids(got))
+			}
+		})
+	}
+	t.Run("respects limit", func(t *testing.T) {
+		got, err := st.ListBookmarksMatchingForUser("user", "", 1)
+		if assert.NoError(t, err) {
+			assert.Len(t, got, 1)
+		}
+	})
+}
+
+func TestDueAtChanged(t *testing.T) {
+	st := NewTestStorage(t)
+	type change struct {
+		id    int64
+		dueAt time.Time
+	}
+	var changes []change
+	st.OnDueAtChanged(func(id int64, dueAt time.Time) {
+		changes = append(changes, change{id, dueAt})
+	})
+	dueAt := time.Now().UTC().Add(time.Hour)
+	bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{DueAt: dueAt})
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, bm.ID, changes[0].id)
+		assert.True(t, dueAt.Equal(changes[0].dueAt))
+	}
+	for _, tc := range []struct {
+		name string
+		fn   func() error
+		want time.Time
+	}{
+		{"set reminder", func() error { return st.SetReminder(bm.ID, bm.UserID, dueAt.Add(time.Hour)) }, dueAt.Add(time.Hour)},
+		{"snooze reminder", func() error { return st.SnoozeReminder(bm.ID, bm.UserID, dueAt.Add(2*time.Hour)) }, dueAt.Add(2 * time.Hour)},
+		{"remove reminder", func() error { return st.RemoveReminder(bm.ID) }, time.Time{}},
+		{"complete reminder", func() error { _, err := st.CompleteReminder(bm.ID); return err }, time.Time{}},
+		{"delete bookmark", func() error { return st.DeleteBookmark(bm.ID, bm.UserID) }, time.Time{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			changes = nil
+			if assert.NoError(t, tc.fn()) {
+				if assert.Len(t, changes, 1) {
+					assert.Equal(t, bm.ID, changes[0].id)
+					assert.True(t, tc.want.Equal(changes[0].dueAt))
+				}
+			}
+		})
+	}
+	t.Run("should not notify when nothing changed", func(t *testing.T) {
+		changes = nil
+		err := st.SetReminder(bm.ID, "other-user", dueAt)
+		assert.Error(t, err)
+		assert.Empty(t, changes)
+	})
+}
+
 func NewTestStorage(t *testing.T) *storage.Storage {
-	db, err := sql.Open("sqlite3", ":memory:")
+	db := NewTestDB(t)
+	return storage.New(db, db)
+}
+
+// NewTestDB returns a new in-memory database with the schema.
+func NewTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:?_fk=on")
 	if err != nil {
 		t.Fatal(err)
 	}
 	if _, err = db.Exec(queries.DDL()); err != nil {
 		t.Fatal(err)
 	}
-	return storage.New(db, db)
+	return db
 }
 
 var (
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// BookmarkSort is the sort order for listing bookmarks.
+type BookmarkSort uint
+
+const (
+	// SortDefault sorts by server, channel and message time.
+	SortDefault BookmarkSort = iota
+	// SortNewestSaved sorts by when the bookmark was saved, newest first.
+	SortNewestSaved
+	// SortOldestMessage sorts by when the message was sent, oldest first.
+	SortOldestMessage
+	// SortDueSoonest sorts by due reminders, soonest first. Bookmarks without reminder come last.
+	SortDueSoonest
+)
+
+// BookmarkFilter is a filter for listing bookmarks. Zero values are ignored.
+type BookmarkFilter struct {
+	AuthorID  string
+	ChannelID string
+	GuildID   string
+	// Only bookmarks with a reminder
+	HasReminder bool
+	// Only bookmarks with a reminder that is past due
+	IsOverdue bool
+	// Only bookmarks for messages sent at or after this time
+	Since time.Time
+	Tag   string
+	// Only bookmarks for messages sent before this time
+	Until time.Time
+}
+
+// bookmarkQuery is a builder for queries on the bookmarks of a user.
+// Filters can be combined and are joined with AND.
+type bookmarkQuery struct {
+	args  []any
+	conds []string
+	order string
+}
+
+// newBookmarkQuery returns a new query for the bookmarks of a user.
+func newBookmarkQuery(userID string) *bookmarkQuery {
+	q := &bookmarkQuery{order: orderByClauses[SortDefault]}
+	return q.where("bookmarks.user_id = ?", userID)
+}
+
+// where adds a condition with arguments for its placeholders.
+func (q *bookmarkQuery) where(cond string, args ...any) *bookmarkQuery {
+	q.conds = append(q.conds, cond)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// filter adds conditions for all fields of a filter which are set.
+func (q *bookmarkQuery) filter(f BookmarkFilter, now time.Time) *bookmarkQuery {
+	if f.AuthorID != "" {
+		q.where("bookmarks.author_id = ?", f.AuthorID)
+	}
+	if f.ChannelID != "" {
+		q.where("bookmarks.channel_id = ?", f.ChannelID)
+	}
+	if f.GuildID != "" {
+		q.where("bookmarks.guild_id = ?", f.GuildID)
+	}
+	if f.HasReminder {
+		q.where("bookmarks.due_at IS NOT NULL")
+	}
+	if f.IsOverdue {
+		q.where("bookmarks.due_at < ?", now.UTC())
+	}
+	if !f.Since.IsZero() {
+		q.where("bookmarks.timestamp >= ?", f.Since.UTC())
+	}
+	if f.Tag != "" {
+		q.where(`EXISTS (
+  SELECT 1 FROM bookmark_tags JOIN tags ON tags.id = bookmark_tags.tag_id
+  WHERE bookmark_tags.bookmark_id = bookmarks.id AND tags.name = ?
+)`, f.Tag)
+	}
+	if !f.Until.IsZero() {
+		q.where("bookmarks.timestamp < ?", f.Until.UTC())
+	}
+	return q
+}
+
+var orderByClauses = map[BookmarkSort]string{
+	SortDefault:       "bookmarks.guild_id, bookmarks.channel_id, bookmarks.timestamp, bookmarks.id",
+	SortNewestSaved:   "bookmarks.created_at DESC, bookmarks.id DESC",
+	SortOldestMessage: "bookmarks.timestamp, bookmarks.id",
+	SortDueSoonest:    "bookmarks.due_at IS NULL, bookmarks.due_at, bookmarks.id",
+}
+
+// sort sets the sort order.
+func (q *bookmarkQuery) sort(s BookmarkSort) *bookmarkQuery {
+	if x, ok := orderByClauses[s]; ok {
+		q.order = x
+	}
+	return q
+}
+
+// selectSQL returns the SQL and arguments for selecting a page of bookmarks.
+func (q *bookmarkQuery) selectSQL(limit, offset int) (string, []any) {
+	s := fmt.Sprintf(
+		"SELECT %s FROM bookmarks WHERE %s ORDER BY %s LIMIT ? OFFSET ?",
+		bookmarkColumns,
+		strings.Join(q.conds, " AND "),
+		q.order,
+	)
+	return s, slices.Concat(q.args, []any{limit, offset})
+}
+
+// countSQL returns the SQL and arguments for counting the bookmarks.
+func (q *bookmarkQuery) countSQL() (string, []any) {
+	s := fmt.Sprintf("SELECT COUNT(*) FROM bookmarks WHERE %s", strings.Join(q.conds, " AND "))
+	return s, q.args
+}
+
+// ListBookmarksPageForUser returns one page of a user's bookmarks and the total number of bookmarks
+// matching the filter. Pages start at 0.
+func (st *Storage) ListBookmarksPageForUser(userID string, f BookmarkFilter, sort BookmarkSort, page int, pageSize int) ([]queries.Bookmark, int, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("ListBookmarksPageForUser: %s: %+v: page %d: %w", userID, f, page, err)
+	}
+	if page < 0 || pageSize < 1 {
+		return nil, 0, wrapErr(fmt.Errorf("invalid page"))
+	}
+	if f.Tag != "" {
+		tag, err := NormalizeTag(f.Tag)
+		if err != nil {
+			return nil, 0, wrapErr(err)
+		}
+		f.Tag = tag
+	}
+	ctx := context.Background()
+	q := newBookmarkQuery(userID).filter(f, time.Now()).sort(sort)
+	var total int
+	query, args := q.countSQL()
+	if err := st.dbRO.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return nil, 0, wrapErr(err)
+	}
+	query, args = q.selectSQL(pageSize, page*pageSize)
+	rows, err := st.dbRO.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, wrapErr(err)
+	}
+	defer rows.Close()
+	bookmarks := make([]queries.Bookmark, 0)
+	for rows.Next() {
+		var bm queries.Bookmark
+		if err := rows.Scan(bookmarkScanDest(&bm)...); err != nil {
+			return nil, 0, wrapErr(err)
+		}
+		bookmarks = append(bookmarks, bm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, wrapErr(err)
+	}
+	return bookmarks, total, nil
+}
@@ -0,0 +1,150 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestListBookmarksPageForUser(t *testing.T) {
+	st := NewTestStorage(t)
+	ClearStorage(t, st)
+	userID := "abc123"
+	now := time.Now().UTC()
+	day := 24 * time.Hour
+	create := func(arg storage.UpdateOrCreateBookmarkParams, tag string) int64 {
+		arg.UserID = userID
+		bm := CreateBookmark(t, st, arg)
+		if tag != "" {
+			if err := st.AddTag(bm.ID, userID, tag); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return bm.ID
+	}
+	bm1 := create(storage.UpdateOrCreateBookmarkParams{
+		AuthorID:  "author-1",
+		ChannelID: "channel-1",
+		GuildID:   "guild-1",
+		Timestamp: now.Add(-3 * day),
+	}, "")
+	bm2 := create(storage.UpdateOrCreateBookmarkParams{
+		AuthorID:  "author-2",
+		ChannelID: "channel-2",
+		DueAt:     now.Add(time.Hour),
+		GuildID:   "guild-1",
+		Timestamp: now.Add(-2 * day),
+	}, "work")
+	bm3 := create(storage.UpdateOrCreateBookmarkParams{
+		AuthorID:  "author-1",
+		ChannelID: "channel-3",
+		DueAt:     now.Add(-time.Hour),
+		GuildID:   "guild-2",
+		Timestamp: now.Add(-1 * day),
+	}, "work")
+	bm4 := create(storage.UpdateOrCreateBookmarkParams{
+		AuthorID:  "author-2",
+		ChannelID: "channel-4",
+		DueAt:     now.Add(2 * time.Hour),
+		GuildID:   "-", // sorts before the other guilds
+		Timestamp: now.Add(-4 * day),
+	}, "")
+	other := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+		AuthorID:  "author-1",
+		ChannelID: "channel-1",
+		DueAt:     now.Add(-time.Hour),
+		GuildID:   "guild-1",
+		Timestamp: now.Add(-2 * day),
+	})
+	if err := st.AddTag(other.ID, other.UserID, "work"); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		filter storage.BookmarkFilter
+		sort   storage.BookmarkSort
+		want   []int64
+	}{
+		{"no filter", storage.BookmarkFilter{}, storage.SortDefault, []int64{bm4, bm1, bm2, bm3}},
+		{"author", storage.BookmarkFilter{AuthorID: "author-1"}, storage.SortDefault, []int64{bm1, bm3}},
+		{"channel", storage.BookmarkFilter{ChannelID: "channel-2"}, storage.SortDefault, []int64{bm2}},
+		{"guild", storage.BookmarkFilter{GuildID: "guild-1"}, storage.SortDefault, []int64{bm1, bm2}},
+		{"has reminder", storage.BookmarkFilter{HasReminder: true}, storage.SortDefault, []int64{bm4, bm2, bm3}},
+		{"overdue", storage.BookmarkFilter{IsOverdue: true}, storage.SortDefault, []int64{bm3}},
+		{"since", storage.BookmarkFilter{Since: now.Add(-60 * time.Hour)}, storage.SortDefault, []int64{bm2, bm3}},
+		{"until", storage.BookmarkFilter{Until: now.Add(-60 * time.Hour)}, storage.SortDefault, []int64{bm4, bm1}},
+		{
+			"date range",
+			storage.BookmarkFilter{Since: now.Add(-84 * time.Hour), Until: now.Add(-36 * time.Hour)},
+			storage.SortDefault,
+			[]int64{bm1, bm2},
+		},
+		{"tag", storage.BookmarkFilter{Tag: "work"}, storage.SortDefault, []int64{bm2, bm3}},
+		{"tag and author", storage.BookmarkFilter{AuthorID: "author-1", Tag: "work"}, storage.SortDefault, []int64{bm3}},
+		{"guild and has reminder", storage.BookmarkFilter{GuildID: "guild-1", HasReminder: true}, storage.SortDefault, []int64{bm2}},
+		{
+			"tag, overdue and since",
+			storage.BookmarkFilter{IsOverdue: true, Since: now.Add(-36 * time.Hour), Tag: "work"},
+			storage.SortDefault,
+			[]int64{bm3},
+		},
+		{"no match", storage.BookmarkFilter{ChannelID: "channel-1", Tag: "work"}, storage.SortDefault, []int64{}},
+		{"sort newest saved", storage.BookmarkFilter{}, storage.SortNewestSaved, []int64{bm4, bm3, bm2, bm1}},
+		{"sort oldest message", storage.BookmarkFilter{}, storage.SortOldestMessage, []int64{bm4, bm1, bm2, bm3}},
+		{"sort due soonest", storage.BookmarkFilter{}, storage.SortDueSoonest, []int64{bm3, bm2, bm4, bm1}},
+		{
+			"author and has reminder sorted by due soonest",
+			storage.BookmarkFilter{AuthorID: "author-2", HasReminder: true},
+			storage.SortDueSoonest,
+			[]int64{bm2, bm4},
+		},
+		{
+			"date range sorted by oldest message",
+			storage.BookmarkFilter{Since: now.Add(-84 * time.Hour)},
+			storage.SortOldestMessage,
+			[]int64{bm1, bm2, bm3},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			xx, total, err := st.ListBookmarksPageForUser(userID, tc.filter, tc.sort, 0, 10)
+			if assert.NoError(t, err) {
+				got := make([]int64, 0)
+				for _, x := range xx {
+					got = append(got, x.ID)
+				}
+				assert.Equal(t, tc.want, got)
+				assert.Equal(t, len(tc.want), total)
+			}
+		})
+	}
+	t.Run("can list by page", func(t *testing.T) {
+		var got []int64
+		for page := range 3 {
+			xx, total, err := st.ListBookmarksPageForUser(userID, storage.BookmarkFilter{}, storage.SortOldestMessage, page, 3)
+			if assert.NoError(t, err) {
+				assert.Equal(t, 4, total)
+				for _, x := range xx {
+					got = append(got, x.ID)
+				}
+			}
+		}
+		assert.Equal(t, []int64{bm4, bm1, bm2, bm3}, got)
+	})
+	t.Run("can list by page with filter", func(t *testing.T) {
+		xx, total, err := st.ListBookmarksPageForUser(userID, storage.BookmarkFilter{Tag: "work"}, storage.SortDefault, 1, 1)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, total)
+			if assert.Len(t, xx, 1) {
+				assert.Equal(t, bm3, xx[0].ID)
+			}
+		}
+	})
+	t.Run("should return error for invalid page", func(t *testing.T) {
+		_, _, err := st.ListBookmarksPageForUser(userID, storage.BookmarkFilter{}, storage.SortDefault, -1, 10)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// DeleteBookmarksByIDForUser deletes the bookmarks of a user with the given IDs
+// and returns how many bookmarks were deleted.
+// IDs of bookmarks which do not exist or belong to another user are ignored.
+func (st *Storage) DeleteBookmarksByIDForUser(userID string, ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	n, err := st.deleteBookmarksForUser(userID, func(ctx context.Context, qtx *queries.Queries) ([]int64, error) {
+		return qtx.DeleteBookmarksByIDForUser(ctx, queries.DeleteBookmarksByIDForUserParams{
+			UserID: userID,
+			Ids:    ids,
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("DeleteBookmarksByIDForUser: %s: %w", userID, err)
+	}
+	return n, nil
+}
+
+// DeleteAllBookmarksForUser deletes all bookmarks of a user
+// and returns how many bookmarks were deleted.
+// Unlike [Storage.DeleteUser] the settings of the user are kept.
+func (st *Storage) DeleteAllBookmarksForUser(userID string) (int, error) {
+	n, err := st.deleteBookmarksForUser(userID, func(ctx context.Context, qtx *queries.Queries) ([]int64, error) {
+		return qtx.DeleteBookmarksForUser(ctx, userID)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("DeleteAllBookmarksForUser: %s: %w", userID, err)
+	}
+	return n, nil
+}
+
+// DeleteBookmarksCreatedBeforeForUser deletes the bookmarks of a user which were created before t
+// and returns how many bookmarks were deleted.
+func (st *Storage) DeleteBookmarksCreatedBeforeForUser(userID string, t time.Time) (int, error) {
+	n, err := st.deleteBookmarksForUser(userID, func(ctx context.Context, qtx *queries.Queries) ([]int64, error) {
+		return qtx.DeleteBookmarksCreatedBeforeForUser(ctx, queries.DeleteBookmarksCreatedBeforeForUserParams{
+			UserID:    userID,
+			CreatedAt: t.UTC(),
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("DeleteBookmarksCreatedBeforeForUser: %s: %w", userID, err)
+	}
+	return n, nil
+}
+
+// DeleteBookmarksWithoutReminderForUser deletes the bookmarks of a user which have no reminder
+// and returns how many bookmarks were deleted.
+func (st *Storage) DeleteBookmarksWithoutReminderForUser(userID string) (int, error) {
+	n, err := st.deleteBookmarksForUser(userID, func(ctx context.Context, qtx *queries.Queries) ([]int64, error) {
+		return qtx.DeleteBookmarksWithoutReminderForUser(ctx, userID)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("DeleteBookmarksWithoutReminderForUser: %s: %w", userID, err)
+	}
+	return n, nil
+}
+
+// deleteBookmarksForUser runs a query deleting bookmarks of a user in one transaction
+// with deleting the tags no longer used by any bookmark, and returns how many bookmarks were deleted.
+// The query must return the IDs of the deleted bookmarks.
+func (st *Storage) deleteBookmarksForUser(userID string, del func(context.Context, *queries.Queries) ([]int64, error)) (int, error) {
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	ids, err := del(ctx, qtx)
+	if err != nil {
+		return 0, err
+	}
+	if err := qtx.DeleteUnusedTags(ctx, userID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		st.notifyDueAtChanged(id, time.Time{})
+	}
+	if len(ids) > 0 {
+		slog.Info("Bookmarks deleted", "user", userID, "count", len(ids))
+	}
+	return len(ids), nil
+}
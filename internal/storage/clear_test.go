@@ -0,0 +1,129 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestDeleteBookmarksForUser(t *testing.T) {
+	db := NewTestDB(t)
+	st := storage.New(db, db)
+	listIDs := func(t *testing.T, userID string) []int64 {
+		t.Helper()
+		bookmarks, err := st.ListBookmarksForUser(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int64, 0)
+		for _, bm := range bookmarks {
+			ids = append(ids, bm.ID)
+		}
+		return ids
+	}
+	t.Run("can delete bookmarks by ID", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		bm2 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		bm3 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		other := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "other"})
+		var changes []int64
+		st.OnDueAtChanged(func(id int64, dueAt time.Time) {
+			if dueAt.IsZero() {
+				changes = append(changes, id)
+			}
+		})
+		defer st.OnDueAtChanged(nil)
+		n, err := st.DeleteBookmarksByIDForUser("user", []int64{bm1.ID, bm3.ID, other.ID, 999})
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, n)
+			assert.ElementsMatch(t, []int64{bm1.ID, bm3.ID}, changes)
+			assert.Equal(t, []int64{bm2.ID}, listIDs(t, "user"))
+			assert.Equal(t, []int64{other.ID}, listIDs(t, "other"))
+		}
+	})
+	t.Run("should delete tags which are no longer used", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		bm2 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		for _, x := range []struct {
+			id  int64
+			tag string
+		}{
+			{bm1.ID, "shared"},
+			{bm1.ID, "only-removed"},
+			{bm2.ID, "shared"},
+		} {
+			if err := st.AddTag(x.id, "user", x.tag); err != nil {
+				t.Fatal(err)
+			}
+		}
+		n, err := st.DeleteBookmarksByIDForUser("user", []int64{bm1.ID})
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+			assert.Equal(t, 1, countTags(t, db, "user"))
+			tags, err := st.ListTagsForBookmark(bm2.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{"shared"}, tags)
+			}
+		}
+	})
+	t.Run("does nothing when no IDs are given", func(t *testing.T) {
+		ClearStorage(t, st)
+		CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		n, err := st.DeleteBookmarksByIDForUser("user", nil)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, n)
+			assert.Len(t, listIDs(t, "user"), 1)
+		}
+	})
+	t.Run("can delete all bookmarks", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		other := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "other"})
+		if err := st.AddTag(bm.ID, "user", "work"); err != nil {
+			t.Fatal(err)
+		}
+		n, err := st.DeleteAllBookmarksForUser("user")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, n)
+			assert.Empty(t, listIDs(t, "user"))
+			assert.Equal(t, 0, countTags(t, db, "user"))
+			assert.Equal(t, []int64{other.ID}, listIDs(t, "other"))
+		}
+	})
+	t.Run("can delete bookmarks created before a time", func(t *testing.T) {
+		ClearStorage(t, st)
+		old := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		recent := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		otherOld := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "other"})
+		createdAt := time.Now().UTC().Add(-10 * 24 * time.Hour)
+		for _, id := range []int64{old.ID, otherOld.ID} {
+			if _, err := db.Exec("UPDATE bookmarks SET created_at = ? WHERE id = ?", createdAt, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		n, err := st.DeleteBookmarksCreatedBeforeForUser("user", time.Now().Add(-7*24*time.Hour))
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+			assert.Equal(t, []int64{recent.ID}, listIDs(t, "user"))
+			assert.Equal(t, []int64{otherOld.ID}, listIDs(t, "other"))
+		}
+	})
+	t.Run("can delete bookmarks without reminder", func(t *testing.T) {
+		ClearStorage(t, st)
+		withReminder := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user", DueAt: time.Now().Add(time.Hour)})
+		CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		other := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "other"})
+		n, err := st.DeleteBookmarksWithoutReminderForUser("user")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+			assert.Equal(t, []int64{withReminder.ID}, listIDs(t, "user"))
+			assert.Equal(t, []int64{other.ID}, listIDs(t, "other"))
+		}
+	})
+}
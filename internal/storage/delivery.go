@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// Status of a reminder delivery
+const (
+	DeliveryDeferred = "deferred" // waiting for the end of the user's quiet hours
+	DeliveryDropped  = "dropped"  // not sent, because it was missed too long ago
+	DeliveryFailed   = "failed"   // given up, the reminder will not be sent again
+	DeliveryHeld     = "held"     // held for the next digest of the user
+	DeliveryPending  = "pending"  // waiting for the next attempt
+	DeliverySending  = "sending"  // claimed by a worker until its lease expires
+	DeliverySent     = "sent"
+)
+
+// Retry policy for delivering reminders
+const (
+	MaxDeliveryAttempts = 5
+	deliveryBackoffBase = time.Minute
+	deliveryBackoffMax  = time.Hour
+)
+
+// deliveryBackoff returns the delay before the next attempt after a number of failed attempts.
+func deliveryBackoff(attempts int64) time.Duration {
+	d := deliveryBackoffBase
+	for range attempts - 1 {
+		d *= 2
+		if d >= deliveryBackoffMax {
+			return deliveryBackoffMax
+		}
+	}
+	return d
+}
+
+// ClaimDueBookmarks claims the due reminders of the bookmarks with the given IDs for an owner
+// and returns those bookmarks. Bookmarks which are not due or already claimed are skipped.
+//
+// Claimed reminders are marked as sending and can not be claimed by others until the lease expires.
+// The owner is expected to record the outcome of each claimed delivery before that.
+// Claiming is atomic, so it is safe for several workers to share the same database.
+//
+// Listeners are notified when the reminders should be tried again:
+// for claimed reminders when their lease expires
+// and for skipped reminders when they are due next, e.g. when the lease of another worker expires.
+func (st *Storage) ClaimDueBookmarks(ids []int64, owner string, lease time.Duration) ([]queries.Bookmark, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("ClaimDueBookmarks: %s: %w", owner, err)
+	}
+	if owner == "" || lease <= 0 {
+		return nil, wrapErr(fmt.Errorf("invalid owner or lease"))
+	}
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	now := time.Now().UTC()
+	due, err := qtx.ListDueBookmarks(ctx, newNullTimeFromTime(now))
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	bookmarks := make([]queries.Bookmark, 0)
+	for _, bm := range due {
+		if !wanted[bm.ID] {
+			continue
+		}
+		err := qtx.ClaimReminderDelivery(ctx, queries.ClaimReminderDeliveryParams{
+			BookmarkID:     bm.ID,
+			CreatedAt:      now,
+			DueAt:          bm.DueAt.Time,
+			LeaseExpiresAt: newNullTimeFromTime(now.Add(lease)),
+			LeaseOwner:     owner,
+			UpdatedAt:      now,
+		})
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		bookmarks = append(bookmarks, bm)
+		delete(wanted, bm.ID)
+	}
+	// reminders which were not claimed, e.g. because another worker holds the lease
+	skipped := make(map[int64]time.Time)
+	if len(wanted) > 0 {
+		rows, err := qtx.ListReminders(ctx)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		for _, r := range rows {
+			if !wanted[r.ID] {
+				continue
+			}
+			if t, ok := nextReminderTime(r); ok {
+				skipped[r.ID] = t
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, wrapErr(err)
+	}
+	for _, bm := range bookmarks {
+		// retry when the owner does not record the outcome in time
+		st.notifyDueAtChanged(bm.ID, now.Add(lease))
+	}
+	for id, t := range skipped {
+		st.notifyDueAtChanged(id, t)
+	}
+	if len(bookmarks) > 0 {
+		slog.Info("Reminders claimed", "owner", owner, "count", len(bookmarks))
+	}
+	return bookmarks, nil
+}
+
+// RecordDeliverySuccess records that the reminder of a bookmark due at dueAt was sent
+// and completes the reminder like [Storage.CompleteReminder].
+// Returns when the next reminder is due or a zero time when there is none.
+func (st *Storage) RecordDeliverySuccess(id int64, dueAt time.Time) (time.Time, error) {
+	next, err := st.completeDelivery(id, dueAt, DeliverySent)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("RecordDeliverySuccess: ID %d: %w", id, err)
+	}
+	return next, nil
+}
+
+// RecordDeliveryDropped records that the reminder of a bookmark due at dueAt was not sent
+// and completes the reminder like [Storage.CompleteReminder].
+// Returns when the next reminder is due or a zero time when there is none.
+func (st *Storage) RecordDeliveryDropped(id int64, dueAt time.Time) (time.Time, error) {
+	next, err := st.completeDelivery(id, dueAt, DeliveryDropped)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("RecordDeliveryDropped: ID %d: %w", id, err)
+	}
+	return next, nil
+}
+
+func (st *Storage) completeDelivery(id int64, dueAt time.Time, status string) (time.Time, error) {
+	// recorded before completing, so the reminder is not sent twice when completing it fails
+	if _, err := st.recordDelivery(id, dueAt, status, nil, time.Time{}); err != nil {
+		return time.Time{}, err
+	}
+	return st.CompleteReminder(id)
+}
+
+// RecordDeliveryFailure records a failed attempt to send the reminder of a bookmark due at dueAt
+// and returns the time of the next attempt.
+// The delivery fails for good after [MaxDeliveryAttempts] or when the cause is permanent.
+// Then the returned time is zero.
+func (st *Storage) RecordDeliveryFailure(id int64, dueAt time.Time, cause error, isPermanent bool) (time.Time, error) {
+	status := DeliveryPending
+	if isPermanent {
+		status = DeliveryFailed
+	}
+	next, err := st.recordDelivery(id, dueAt, status, cause, time.Time{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("RecordDeliveryFailure: ID %d: %w", id, err)
+	}
+	st.notifyDueAtChanged(id, next)
+	return next, nil
+}
+
+// recordDelivery records an attempt to send a reminder and returns the time of the next attempt if any.
+// A pending delivery fails after too many attempts. A deferred delivery is attempted again at deferUntil.
+func (st *Storage) recordDelivery(id int64, dueAt time.Time, status string, cause error, deferUntil time.Time) (time.Time, error) {
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	dueAt = dueAt.UTC()
+	var attempts int64
+	d, err := qtx.GetReminderDelivery(ctx, queries.GetReminderDeliveryParams{BookmarkID: id, DueAt: dueAt})
+	if err == nil {
+		attempts = d.Attempts
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, err
+	}
+	if status != DeliveryHeld && status != DeliveryDeferred {
+		attempts++
+	}
+	now := time.Now().UTC()
+	if status == DeliveryPending && attempts >= MaxDeliveryAttempts {
+		status = DeliveryFailed
+	}
+	arg := queries.UpdateOrCreateReminderDeliveryParams{
+		Attempts:   attempts,
+		BookmarkID: id,
+		CreatedAt:  now,
+		DueAt:      dueAt,
+		Status:     status,
+		UpdatedAt:  now,
+	}
+	if cause != nil {
+		arg.Error = cause.Error()
+	}
+	var next time.Time
+	switch status {
+	case DeliveryDeferred:
+		next = deferUntil.UTC()
+	case DeliveryPending:
+		next = now.Add(deliveryBackoff(attempts))
+	}
+	arg.NextAttemptAt = newNullTimeFromTime(next)
+	if err := qtx.UpdateOrCreateReminderDelivery(ctx, arg); err != nil {
+		return time.Time{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, err
+	}
+	slog.Info("Reminder delivery recorded", "id", id, "status", arg.Status, "attempts", attempts)
+	return next, nil
+}
+
+// HoldDelivery holds the reminder of a bookmark due at dueAt for the next digest.
+// Held reminders are not due anymore and are listed by [Storage.ListHeldBookmarksForUser].
+func (st *Storage) HoldDelivery(id int64, dueAt time.Time) error {
+	if _, err := st.recordDelivery(id, dueAt, DeliveryHeld, nil, time.Time{}); err != nil {
+		return fmt.Errorf("HoldDelivery: ID %d: %w", id, err)
+	}
+	st.notifyDueAtChanged(id, time.Time{})
+	return nil
+}
+
+// DeferDelivery defers the reminder of a bookmark due at dueAt until a later time,
+// e.g. the end of the user's quiet hours. This does not count as an attempt.
+func (st *Storage) DeferDelivery(id int64, dueAt time.Time, until time.Time) error {
+	next, err := st.recordDelivery(id, dueAt, DeliveryDeferred, nil, until)
+	if err != nil {
+		return fmt.Errorf("DeferDelivery: ID %d: %w", id, err)
+	}
+	st.notifyDueAtChanged(id, next)
+	return nil
+}
+
+// ListHeldBookmarksForUser returns the bookmarks of a user with reminders held for the next digest
+// ordered by due time.
+func (st *Storage) ListHeldBookmarksForUser(userID string) ([]queries.Bookmark, error) {
+	bb, err := st.qRO.ListHeldBookmarksForUser(context.Background(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("ListHeldBookmarksForUser: %s: %w", userID, err)
+	}
+	return bb, nil
+}
+
+// ListUpcomingBookmarksForUser returns the bookmarks of a user with reminders due after now until a time
+// ordered by due time.
+func (st *Storage) ListUpcomingBookmarksForUser(userID string, until time.Time) ([]queries.Bookmark, error) {
+	bb, err := st.qRO.ListUpcomingBookmarksForUser(context.Background(), queries.ListUpcomingBookmarksForUserParams{
+		UserID: userID,
+		Now:    newNullTimeFromTime(time.Now().UTC()),
+		Until:  newNullTimeFromTime(until.UTC()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ListUpcomingBookmarksForUser: %s: %w", userID, err)
+	}
+	return bb, nil
+}
+
+// ReleaseHeldReminders makes the reminders of a user held for the next digest due again
+// and returns how many were released. This is needed when the user turns off the digest.
+func (st *Storage) ReleaseHeldReminders(userID string) (int, error) {
+	now := time.Now().UTC()
+	ids, err := st.qRW.ReleaseHeldReminderDeliveries(context.Background(), queries.ReleaseHeldReminderDeliveriesParams{
+		Now:    newNullTimeFromTime(now),
+		UserID: userID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ReleaseHeldReminders: %s: %w", userID, err)
+	}
+	for _, id := range ids {
+		st.notifyDueAtChanged(id, now)
+	}
+	if len(ids) > 0 {
+		slog.Info("Held reminders released", "user", userID, "count", len(ids))
+	}
+	return len(ids), nil
+}
+
+// GetFailedDelivery returns the failed delivery of the current reminder of a bookmark.
+// Returns [sql.ErrNoRows] when the delivery has not failed.
+func (st *Storage) GetFailedDelivery(id int64) (queries.ReminderDelivery, error) {
+	d, err := st.qRO.GetFailedReminderDelivery(context.Background(), id)
+	if err != nil {
+		return queries.ReminderDelivery{}, fmt.Errorf("GetFailedDelivery: ID %d: %w", id, err)
+	}
+	return d, nil
+}
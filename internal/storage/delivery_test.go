@@ -0,0 +1,435 @@
+package storage_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/queries"
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestReminderDelivery(t *testing.T) {
+	db := NewTestDB(t)
+	st := storage.New(db, db)
+	errSend := errors.New("send failed")
+	dueIDs := func(t *testing.T) []int64 {
+		bb, err := st.ListDueBookmarks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int64, 0)
+		for _, bm := range bb {
+			ids = append(ids, bm.ID)
+		}
+		return ids
+	}
+	createDue := func(t *testing.T) (int64, time.Time) {
+		dueAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{DueAt: dueAt})
+		return bm.ID, dueAt
+	}
+	t.Run("should retry failed delivery with backoff", func(t *testing.T) {
+		id, dueAt := createDue(t)
+		now := time.Now()
+		next1, err := st.RecordDeliveryFailure(id, dueAt, errSend, false)
+		if assert.NoError(t, err) {
+			assert.WithinDuration(t, now.Add(time.Minute), next1, 5*time.Second)
+			assert.NotContains(t, dueIDs(t), id)
+			m, err := st.ListReminders()
+			if assert.NoError(t, err) {
+				assert.True(t, next1.Equal(m[id]))
+			}
+		}
+		retryNow(t, db, id)
+		assert.Contains(t, dueIDs(t), id)
+		next2, err := st.RecordDeliveryFailure(id, dueAt, errSend, false)
+		if assert.NoError(t, err) {
+			assert.WithinDuration(t, now.Add(2*time.Minute), next2, 5*time.Second)
+		}
+	})
+	t.Run("should give up after max attempts", func(t *testing.T) {
+		id, dueAt := createDue(t)
+		_, err := st.GetFailedDelivery(id)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		var next time.Time
+		for range storage.MaxDeliveryAttempts {
+			next, err = st.RecordDeliveryFailure(id, dueAt, errSend, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			retryNow(t, db, id)
+		}
+		assert.True(t, next.IsZero())
+		assert.NotContains(t, dueIDs(t), id)
+		m, err := st.ListReminders()
+		if assert.NoError(t, err) {
+			assert.NotContains(t, m, id)
+		}
+		d, err := st.GetFailedDelivery(id)
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, storage.MaxDeliveryAttempts, d.Attempts)
+			assert.Equal(t, storage.DeliveryFailed, d.Status)
+			assert.Equal(t, "send failed", d.Error)
+		}
+	})
+	t.Run("should give up at once when error is permanent", func(t *testing.T) {
+		id, dueAt := createDue(t)
+		next, err := st.RecordDeliveryFailure(id, dueAt, errSend, true)
+		if assert.NoError(t, err) {
+			assert.True(t, next.IsZero())
+			d, err := st.GetFailedDelivery(id)
+			if assert.NoError(t, err) {
+				assert.EqualValues(t, 1, d.Attempts)
+			}
+		}
+	})
+	t.Run("should deliver again when failed reminder is set again", func(t *testing.T) {
+		id, dueAt := createDue(t)
+		bm, err := st.GetBookmark(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.RecordDeliveryFailure(id, dueAt, errSend, true); err != nil {
+			t.Fatal(err)
+		}
+		err = st.SetReminder(id, bm.UserID, dueAt.Add(-time.Minute))
+		if assert.NoError(t, err) {
+			assert.Contains(t, dueIDs(t), id)
+			_, err := st.GetFailedDelivery(id)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		}
+	})
+	t.Run("should defer delivery without counting an attempt", func(t *testing.T) {
+		id, dueAt := createDue(t)
+		until := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+		err := st.DeferDelivery(id, dueAt, until)
+		if assert.NoError(t, err) {
+			assert.NotContains(t, dueIDs(t), id)
+			m, err := st.ListReminders()
+			if assert.NoError(t, err) {
+				assert.True(t, until.Equal(m[id]))
+			}
+			retryNow(t, db, id)
+			assert.Contains(t, dueIDs(t), id)
+			if _, err := st.RecordDeliveryFailure(id, dueAt, errSend, true); err != nil {
+				t.Fatal(err)
+			}
+			d, err := st.GetFailedDelivery(id)
+			if assert.NoError(t, err) {
+				assert.EqualValues(t, 1, d.Attempts)
+			}
+		}
+	})
+	t.Run("should complete reminder after successful delivery", func(t *testing.T) {
+		id, dueAt := createDue(t)
+		if _, err := st.RecordDeliveryFailure(id, dueAt, errSend, false); err != nil {
+			t.Fatal(err)
+		}
+		next, err := st.RecordDeliverySuccess(id, dueAt)
+		if assert.NoError(t, err) {
+			assert.True(t, next.IsZero())
+			bm, err := st.GetBookmark(id)
+			if assert.NoError(t, err) {
+				assert.False(t, bm.DueAt.Valid)
+			}
+			assert.NotContains(t, dueIDs(t), id)
+		}
+	})
+	t.Run("should complete reminder when delivery is dropped", func(t *testing.T) {
+		id, dueAt := createDue(t)
+		next, err := st.RecordDeliveryDropped(id, dueAt)
+		if assert.NoError(t, err) {
+			assert.True(t, next.IsZero())
+			bm, err := st.GetBookmark(id)
+			if assert.NoError(t, err) {
+				assert.False(t, bm.DueAt.Valid)
+			}
+		}
+	})
+	t.Run("should notify about next attempt", func(t *testing.T) {
+		id, dueAt := createDue(t)
+		var got []time.Time
+		st.OnDueAtChanged(func(id2 int64, t time.Time) {
+			if id2 == id {
+				got = append(got, t)
+			}
+		})
+		defer st.OnDueAtChanged(nil)
+		next, err := st.RecordDeliveryFailure(id, dueAt, errSend, false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []time.Time{next}, got)
+		}
+	})
+}
+
+func TestClaimDueBookmarks(t *testing.T) {
+	db := NewTestDB(t)
+	st := storage.New(db, db)
+	createDue := func(t *testing.T) queries.Bookmark {
+		dueAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+		return CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{DueAt: dueAt})
+	}
+	claim := func(t *testing.T, owner string, ids ...int64) []int64 {
+		bb, err := st.ClaimDueBookmarks(ids, owner, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := make([]int64, 0)
+		for _, bm := range bb {
+			r = append(r, bm.ID)
+		}
+		return r
+	}
+	t.Run("should claim due bookmarks once", func(t *testing.T) {
+		bm1 := createDue(t)
+		bm2 := createDue(t)
+		assert.Equal(t, []int64{bm1.ID}, claim(t, "a", bm1.ID))
+		assert.Empty(t, claim(t, "b", bm1.ID))
+		assert.Equal(t, []int64{bm2.ID}, claim(t, "b", bm1.ID, bm2.ID))
+	})
+	t.Run("should not claim bookmarks which are not due", func(t *testing.T) {
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{DueAt: time.Now().UTC().Add(time.Hour)})
+		assert.Empty(t, claim(t, "a", bm.ID))
+	})
+	t.Run("should claim again when lease expired", func(t *testing.T) {
+		bm := createDue(t)
+		assert.Equal(t, []int64{bm.ID}, claim(t, "a", bm.ID))
+		_, err := db.Exec(
+			"UPDATE reminder_deliveries SET lease_expires_at = ? WHERE bookmark_id = ?",
+			time.Now().UTC().Add(-time.Second),
+			bm.ID,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []int64{bm.ID}, claim(t, "b", bm.ID))
+	})
+	t.Run("should not claim after delivery was recorded", func(t *testing.T) {
+		bm := createDue(t)
+		assert.Equal(t, []int64{bm.ID}, claim(t, "a", bm.ID))
+		if _, err := st.RecordDeliveryFailure(bm.ID, bm.DueAt.Time, errors.New("send failed"), true); err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, claim(t, "b", bm.ID))
+	})
+	t.Run("should keep attempts when claiming a retry", func(t *testing.T) {
+		bm := createDue(t)
+		claim(t, "a", bm.ID)
+		if _, err := st.RecordDeliveryFailure(bm.ID, bm.DueAt.Time, errors.New("send failed"), false); err != nil {
+			t.Fatal(err)
+		}
+		retryNow(t, db, bm.ID)
+		assert.Equal(t, []int64{bm.ID}, claim(t, "a", bm.ID))
+		for range storage.MaxDeliveryAttempts - 1 {
+			if _, err := st.RecordDeliveryFailure(bm.ID, bm.DueAt.Time, errors.New("send failed"), false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := st.GetFailedDelivery(bm.ID)
+		assert.NoError(t, err)
+	})
+	t.Run("should report lease expiry as next due time", func(t *testing.T) {
+		bm := createDue(t)
+		var got time.Time
+		st.OnDueAtChanged(func(id int64, dueAt time.Time) {
+			got = dueAt
+		})
+		defer st.OnDueAtChanged(nil)
+		claim(t, "a", bm.ID)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), got, 5*time.Second)
+		m, err := st.ListReminders()
+		if assert.NoError(t, err) {
+			assert.True(t, got.Equal(m[bm.ID]))
+		}
+	})
+}
+
+func TestClaimDueBookmarksConcurrently(t *testing.T) {
+	// workers with their own connections to a shared database, like separate processes
+	dsn := "file:///" + filepath.ToSlash(filepath.Join(t.TempDir(), "test.sqlite"))
+	const workers = 8
+	stores := make([]*storage.Storage, workers)
+	for i := range workers {
+		dbRW, dbRO, err := storage.InitDB(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			dbRW.Close()
+			dbRO.Close()
+		})
+		stores[i] = storage.New(dbRW, dbRO)
+	}
+	t.Run("should claim each bookmark once", func(t *testing.T) {
+		ids := make([]int64, 0)
+		for range 50 {
+			bm := CreateBookmark(t, stores[0], storage.UpdateOrCreateBookmarkParams{
+				DueAt: time.Now().UTC().Add(-time.Minute),
+			})
+			ids = append(ids, bm.ID)
+		}
+		var (
+			mu      sync.Mutex
+			claimed = make(map[int64][]string)
+			wg      sync.WaitGroup
+		)
+		for i, st := range stores {
+			owner := fmt.Sprintf("worker-%d", i)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 5 {
+					bb, err := st.ClaimDueBookmarks(ids, owner, time.Minute)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					mu.Lock()
+					for _, bm := range bb {
+						claimed[bm.ID] = append(claimed[bm.ID], owner)
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Len(t, claimed, len(ids))
+		for id, owners := range claimed {
+			assert.Len(t, owners, 1, "bookmark %d claimed by %v", id, owners)
+		}
+	})
+	t.Run("should recover reminders from a worker which never records the delivery", func(t *testing.T) {
+		crashed, survivor := stores[0], stores[1]
+		bm := CreateBookmark(t, crashed, storage.UpdateOrCreateBookmarkParams{
+			DueAt: time.Now().UTC().Add(-time.Minute),
+		})
+		const lease = 500 * time.Millisecond
+		bb, err := crashed.ClaimDueBookmarks([]int64{bm.ID}, "crashed", lease)
+		if err != nil || len(bb) != 1 {
+			t.Fatalf("claim failed: %v, %v", bb, err)
+		}
+		// the survivor skips the claimed reminder and is told when the lease expires
+		var retry time.Time
+		survivor.OnDueAtChanged(func(id int64, dueAt time.Time) {
+			if id == bm.ID {
+				retry = dueAt
+			}
+		})
+		defer survivor.OnDueAtChanged(nil)
+		bb, err = survivor.ClaimDueBookmarks([]int64{bm.ID}, "survivor", time.Minute)
+		if assert.NoError(t, err) {
+			assert.Empty(t, bb)
+			assert.WithinDuration(t, time.Now().Add(lease), retry, lease)
+		}
+		time.Sleep(time.Until(retry) + 10*time.Millisecond)
+		// the reminder is due again for a safety sweep of any worker
+		due, err := survivor.ListDueBookmarks()
+		if assert.NoError(t, err) {
+			assert.Contains(t, bookmarkIDs(due), bm.ID)
+		}
+		bb, err = survivor.ClaimDueBookmarks([]int64{bm.ID}, "survivor", time.Minute)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []int64{bm.ID}, bookmarkIDs(bb))
+		}
+	})
+}
+
+func bookmarkIDs(bookmarks []queries.Bookmark) []int64 {
+	ids := make([]int64, 0, len(bookmarks))
+	for _, bm := range bookmarks {
+		ids = append(ids, bm.ID)
+	}
+	return ids
+}
+
+func TestHoldDelivery(t *testing.T) {
+	db := NewTestDB(t)
+	st := storage.New(db, db)
+	now := time.Now().UTC().Truncate(time.Second)
+	bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{DueAt: now.Add(-time.Minute)})
+	bm2 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{DueAt: now.Add(time.Hour), UserID: bm1.UserID})
+	bm3 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{DueAt: now.Add(3 * time.Hour), UserID: bm1.UserID})
+	ids := func(bb []queries.Bookmark) []int64 {
+		r := make([]int64, 0)
+		for _, bm := range bb {
+			r = append(r, bm.ID)
+		}
+		return r
+	}
+	t.Run("should list held bookmarks and not list them as due", func(t *testing.T) {
+		err := st.HoldDelivery(bm1.ID, bm1.DueAt.Time)
+		if assert.NoError(t, err) {
+			held, err := st.ListHeldBookmarksForUser(bm1.UserID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []int64{bm1.ID}, ids(held))
+			}
+			due, err := st.ListDueBookmarks()
+			if assert.NoError(t, err) {
+				assert.NotContains(t, ids(due), bm1.ID)
+			}
+			m, err := st.ListReminders()
+			if assert.NoError(t, err) {
+				assert.NotContains(t, m, bm1.ID)
+			}
+		}
+	})
+	t.Run("should list upcoming bookmarks", func(t *testing.T) {
+		got, err := st.ListUpcomingBookmarksForUser(bm1.UserID, now.Add(2*time.Hour))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []int64{bm2.ID}, ids(got))
+		}
+		got, err = st.ListUpcomingBookmarksForUser(bm1.UserID, now.Add(4*time.Hour))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []int64{bm2.ID, bm3.ID}, ids(got))
+		}
+	})
+	t.Run("should release held reminders", func(t *testing.T) {
+		n, err := st.ReleaseHeldReminders(bm1.UserID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+			held, err := st.ListHeldBookmarksForUser(bm1.UserID)
+			if assert.NoError(t, err) {
+				assert.Empty(t, held)
+			}
+			due, err := st.ListDueBookmarks()
+			if assert.NoError(t, err) {
+				assert.Contains(t, ids(due), bm1.ID)
+			}
+		}
+	})
+	t.Run("should complete held reminder when digest was sent", func(t *testing.T) {
+		if err := st.HoldDelivery(bm1.ID, bm1.DueAt.Time); err != nil {
+			t.Fatal(err)
+		}
+		_, err := st.RecordDeliverySuccess(bm1.ID, bm1.DueAt.Time)
+		if assert.NoError(t, err) {
+			held, err := st.ListHeldBookmarksForUser(bm1.UserID)
+			if assert.NoError(t, err) {
+				assert.Empty(t, held)
+			}
+			bm, err := st.GetBookmark(bm1.ID)
+			if assert.NoError(t, err) {
+				assert.False(t, bm.DueAt.Valid)
+			}
+		}
+	})
+}
+
+// retryNow makes the next attempt to deliver the reminder of a bookmark due now.
+func retryNow(t *testing.T, db *sql.DB, id int64) {
+	_, err := db.Exec(
+		"UPDATE reminder_deliveries SET next_attempt_at = ? WHERE bookmark_id = ? AND status IN ('deferred', 'pending')",
+		time.Now().UTC().Add(-time.Second),
+		id,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !sqlite_fts5
+
+package storage
+
+// The full-text search needs SQLite with the FTS5 module,
+// which go-sqlite3 only includes when built with the sqlite_fts5 tag.
+// Without it the bot would fail at startup when migrating the database,
+// so building without the tag fails instead, e.g. with:
+//
+//	undefined: buildWithTagSqliteFTS5
+//
+// Build with: go build -tags sqlite_fts5 ./...
+var _ = buildWithTagSqliteFTS5
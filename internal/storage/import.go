@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// ImportBookmarkParams are the params for importing a bookmark.
+type ImportBookmarkParams struct {
+	UpdateOrCreateBookmarkParams
+	Note       string
+	Recurrence string
+	Tags       []string
+}
+
+// normalize returns the params with normalized note, recurrence and tags
+// and reports whether they are valid.
+func (arg ImportBookmarkParams) normalize() (ImportBookmarkParams, bool) {
+	if !arg.isValid() {
+		return arg, false
+	}
+	arg.Note = strings.TrimSpace(arg.Note)
+	if utf8.RuneCountInString(arg.Note) > MaxNoteLength {
+		return arg, false
+	}
+	if arg.Recurrence != "" {
+		r, err := ParseRecurrenceRule(arg.Recurrence)
+		if err != nil || arg.DueAt.IsZero() {
+			return arg, false
+		}
+		arg.Recurrence = r.String()
+	}
+	tags, err := ParseTags(strings.Join(arg.Tags, ","))
+	if err != nil || len(tags) > MaxTagsPerBookmark {
+		return arg, false
+	}
+	arg.Tags = tags
+	return arg, true
+}
+
+// ImportResult reports the outcome of importing bookmarks.
+type ImportResult struct {
+	Created int
+	Updated int
+	Skipped int
+}
+
+// ImportBookmarks creates or updates bookmarks for a user in a single transaction.
+// Note, recurrence and tags of existing bookmarks are replaced by the imported ones.
+//
+// Invalid bookmarks are skipped and so are new bookmarks when the user would have more than maxBookmarks.
+// Nothing is imported when an error is returned.
+func (st *Storage) ImportBookmarks(userID string, bookmarks []ImportBookmarkParams, maxBookmarks int) (ImportResult, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("ImportBookmarks: %s: %w", userID, err)
+	}
+	var r ImportResult
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return r, wrapErr(err)
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	total, err := qtx.CountBookmarks(ctx, userID)
+	if err != nil {
+		return r, wrapErr(err)
+	}
+	dueAt := make(map[int64]time.Time)
+	now := time.Now().UTC()
+	for _, arg := range bookmarks {
+		arg.UserID = userID
+		arg, ok := arg.normalize()
+		if !ok {
+			r.Skipped++
+			continue
+		}
+		idArg := queries.GetBookmarkIDForMessageParams{
+			ChannelID: arg.ChannelID,
+			GuildID:   arg.GuildID,
+			MessageID: arg.MessageID,
+			UserID:    userID,
+		}
+		_, err := qtx.GetBookmarkIDForMessage(ctx, idArg)
+		created := errors.Is(err, sql.ErrNoRows)
+		if err != nil && !created {
+			return ImportResult{}, wrapErr(err)
+		}
+		if created && int(total) >= maxBookmarks {
+			r.Skipped++
+			continue
+		}
+		err = qtx.UpdateOrCreateBookmark(ctx, queries.UpdateOrCreateBookmarkParams{
+			AuthorID:  arg.AuthorID,
+			ChannelID: arg.ChannelID,
+			Content:   arg.Content,
+			CreatedAt: now,
+			DueAt:     newNullTimeFromTime(arg.DueAt.UTC()),
+			GuildID:   arg.GuildID,
+			MessageID: arg.MessageID,
+			Timestamp: arg.Timestamp.UTC(),
+			UpdatedAt: now,
+			UserID:    userID,
+		})
+		if err != nil {
+			return ImportResult{}, wrapErr(err)
+		}
+		id, err := qtx.GetBookmarkIDForMessage(ctx, idArg)
+		if err != nil {
+			return ImportResult{}, wrapErr(err)
+		}
+		if _, err := qtx.UpdateBookmarkNoteForUser(ctx, queries.UpdateBookmarkNoteForUserParams{
+			ID:        id,
+			Note:      sql.NullString{String: arg.Note, Valid: arg.Note != ""},
+			UpdatedAt: now,
+			UserID:    userID,
+		}); err != nil {
+			return ImportResult{}, wrapErr(err)
+		}
+		if _, err := qtx.UpdateBookmarkRecurrenceForUser(ctx, queries.UpdateBookmarkRecurrenceForUserParams{
+			ID:         id,
+			Recurrence: arg.Recurrence,
+			UserID:     userID,
+		}); err != nil {
+			return ImportResult{}, wrapErr(err)
+		}
+		if err := qtx.DeleteBookmarkTags(ctx, id); err != nil {
+			return ImportResult{}, wrapErr(err)
+		}
+		for _, name := range arg.Tags {
+			if err := addTag(ctx, qtx, id, userID, name); err != nil {
+				return ImportResult{}, wrapErr(err)
+			}
+		}
+		dueAt[id] = arg.DueAt
+		if created {
+			total++
+			r.Created++
+		} else {
+			r.Updated++
+		}
+	}
+	if err := qtx.DeleteUnusedTags(ctx, userID); err != nil {
+		return ImportResult{}, wrapErr(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return ImportResult{}, wrapErr(err)
+	}
+	for id, t := range dueAt {
+		st.notifyDueAtChanged(id, t)
+	}
+	slog.Info("Bookmarks imported", "user", userID, "created", r.Created, "updated", r.Updated, "skipped", r.Skipped)
+	return r, nil
+}
@@ -0,0 +1,131 @@
+package storage_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func makeImportBookmark(messageID string) storage.ImportBookmarkParams {
+	return storage.ImportBookmarkParams{
+		UpdateOrCreateBookmarkParams: storage.UpdateOrCreateBookmarkParams{
+			AuthorID:  "author",
+			ChannelID: "channel",
+			Content:   "content",
+			GuildID:   "guild",
+			MessageID: messageID,
+			Timestamp: time.Now(),
+		},
+	}
+}
+
+func TestImportBookmarks(t *testing.T) {
+	st := NewTestStorage(t)
+	t.Run("can create bookmarks with note, recurrence and tags", func(t *testing.T) {
+		ClearStorage(t, st)
+		dueAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		arg := makeImportBookmark("1")
+		arg.DueAt = dueAt
+		arg.Note = " note "
+		arg.Recurrence = "weekly"
+		arg.Tags = []string{"Work", "read later"}
+		var changes []int64
+		st.OnDueAtChanged(func(id int64, _ time.Time) {
+			changes = append(changes, id)
+		})
+		defer st.OnDueAtChanged(nil)
+		r, err := st.ImportBookmarks("user", []storage.ImportBookmarkParams{arg, makeImportBookmark("2")}, 100)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, storage.ImportResult{Created: 2}, r)
+		bookmarks, err := st.ListBookmarksForUser("user")
+		if !assert.NoError(t, err) || !assert.Len(t, bookmarks, 2) {
+			return
+		}
+		var bm = bookmarks[0]
+		if bm.MessageID != "1" {
+			bm = bookmarks[1]
+		}
+		assert.Equal(t, "note", bm.Note.String)
+		assert.Equal(t, "FREQ=WEEKLY", bm.Recurrence)
+		assert.True(t, dueAt.Equal(bm.DueAt.Time))
+		tags, err := st.ListTagsForBookmark(bm.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"read-later", "work"}, tags)
+		}
+		assert.Len(t, changes, 2)
+	})
+	t.Run("can update existing bookmarks", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		if err := st.AddTag(bm.ID, "user", "old"); err != nil {
+			t.Fatal(err)
+		}
+		arg := makeImportBookmark(bm.MessageID)
+		arg.ChannelID = bm.ChannelID
+		arg.GuildID = bm.GuildID
+		arg.Tags = []string{"new"}
+		r, err := st.ImportBookmarks("user", []storage.ImportBookmarkParams{arg}, 100)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, storage.ImportResult{Updated: 1}, r)
+		tags, err := st.ListTagsForUser("user")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []storage.Tag{{Name: "new", Bookmarks: 1}}, tags)
+		}
+	})
+	t.Run("should skip invalid bookmarks", func(t *testing.T) {
+		ClearStorage(t, st)
+		noMessage := makeImportBookmark("")
+		longNote := makeImportBookmark("2")
+		longNote.Note = strings.Repeat("x", storage.MaxNoteLength+1)
+		badRecurrence := makeImportBookmark("3")
+		badRecurrence.DueAt = time.Now().Add(time.Hour)
+		badRecurrence.Recurrence = "sometimes"
+		recurrenceWithoutDue := makeImportBookmark("4")
+		recurrenceWithoutDue.Recurrence = "daily"
+		badTag := makeImportBookmark("5")
+		badTag.Tags = []string{"work!"}
+		r, err := st.ImportBookmarks("user", []storage.ImportBookmarkParams{
+			noMessage, longNote, badRecurrence, recurrenceWithoutDue, badTag, makeImportBookmark("6"),
+		}, 100)
+		if assert.NoError(t, err) {
+			assert.Equal(t, storage.ImportResult{Created: 1, Skipped: 5}, r)
+		}
+	})
+	t.Run("should skip new bookmarks beyond the maximum", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		existing := makeImportBookmark(bm.MessageID)
+		existing.ChannelID = bm.ChannelID
+		existing.GuildID = bm.GuildID
+		r, err := st.ImportBookmarks("user", []storage.ImportBookmarkParams{
+			makeImportBookmark("1"), makeImportBookmark("2"), existing,
+		}, 2)
+		if assert.NoError(t, err) {
+			assert.Equal(t, storage.ImportResult{Created: 1, Updated: 1, Skipped: 1}, r)
+		}
+		n, err := st.CountBookmarksForUser("user")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, n)
+		}
+	})
+	t.Run("should import bookmarks for the given user only", func(t *testing.T) {
+		ClearStorage(t, st)
+		arg := makeImportBookmark("1")
+		arg.UserID = "other"
+		_, err := st.ImportBookmarks("user", []storage.ImportBookmarkParams{arg}, 100)
+		if assert.NoError(t, err) {
+			n, err := st.CountBookmarksForUser("other")
+			if assert.NoError(t, err) {
+				assert.Equal(t, 0, n)
+			}
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles contains the migrations for the database schema.
+// Each file is named after its version and a short name, e.g. "0002_user_settings.sql".
+// Migrations must never be changed once released. Add a new migration instead.
+//
+// The first migration is the baseline schema from before migrations were introduced.
+// It must not fail for existing databases, which were created without migrations.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a step for upgrading the database schema.
+type Migration struct {
+	Version int
+	Name    string
+	// AppliedAt is when the migration was applied and zero when it is pending.
+	AppliedAt time.Time
+
+	sql string
+}
+
+// IsApplied reports whether the migration was applied.
+func (m Migration) IsApplied() bool {
+	return !m.AppliedAt.IsZero()
+}
+
+const ddlSchemaMigrations = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  applied_at DATETIME NOT NULL
+);`
+
+// loadMigrations returns all migrations ordered by version.
+func loadMigrations() ([]Migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	mm := make([]Migration, 0, len(names))
+	for i, p := range names { // sorted by name
+		base := strings.TrimSuffix(path.Base(p), ".sql")
+		v, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration name: %s", p)
+		}
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name: %s: %w", p, err)
+		}
+		if version != i+1 {
+			return nil, fmt.Errorf("migration %s: expected version %d", p, i+1)
+		}
+		data, err := migrationFiles.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		mm = append(mm, Migration{Version: version, Name: name, sql: string(data)})
+	}
+	return mm, nil
+}
+
+// LatestSchemaVersion returns the version of the database schema after all migrations were applied.
+func LatestSchemaVersion() int {
+	mm, err := loadMigrations()
+	if err != nil {
+		panic(err) // the embedded migrations are broken
+	}
+	return len(mm)
+}
+
+// SchemaVersion returns the current version of the database schema.
+// The version is 0 for databases without applied migrations.
+func SchemaVersion(db *sql.DB) (int, error) {
+	var exists bool
+	err := db.QueryRow(
+		"SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'",
+	).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("SchemaVersion: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+	var v int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&v); err != nil {
+		return 0, fmt.Errorf("SchemaVersion: %w", err)
+	}
+	return v, nil
+}
+
+// MigrationStatus returns all migrations ordered by version and reports which were applied.
+func MigrationStatus(db *sql.DB) ([]Migration, error) {
+	mm, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("MigrationStatus: %w", err)
+	}
+	v, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if v == 0 {
+		return mm, nil
+	}
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("MigrationStatus: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("MigrationStatus: %w", err)
+		}
+		if version >= 1 && version <= len(mm) {
+			mm[version-1].AppliedAt = appliedAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("MigrationStatus: %w", err)
+	}
+	return mm, nil
+}
+
+// Migrate upgrades the database schema to the latest version and returns the number of applied migrations.
+// Each migration is applied in its own transaction, so a failed migration leaves the database at the previous version.
+// Returns an error when the database has a newer schema than this build knows.
+func Migrate(db *sql.DB) (int, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("Migrate: %w", err)
+	}
+	mm, err := loadMigrations()
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+	if _, err := db.Exec(ddlSchemaMigrations); err != nil {
+		return 0, wrapErr(err)
+	}
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	if current > len(mm) {
+		return 0, wrapErr(fmt.Errorf("database schema version %d is newer than %d", current, len(mm)))
+	}
+	var n int
+	for _, m := range mm[current:] {
+		if err := applyMigration(db, m); err != nil {
+			return n, wrapErr(fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err))
+		}
+		slog.Info("Migration applied", "version", m.Version, "name", m.Name)
+		n++
+	}
+	return n, nil
+}
+
+func applyMigration(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(m.sql); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		m.Version, m.Name, time.Now().UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,185 @@
+package storage_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Run("can create new database", func(t *testing.T) {
+		db := newEmptyTestDB(t)
+		n, err := storage.Migrate(db)
+		if assert.NoError(t, err) {
+			assert.Equal(t, storage.LatestSchemaVersion(), n)
+			v, err := storage.SchemaVersion(db)
+			if assert.NoError(t, err) {
+				assert.Equal(t, storage.LatestSchemaVersion(), v)
+			}
+		}
+	})
+	t.Run("should create the same schema as schema.sql", func(t *testing.T) {
+		db := newEmptyTestDB(t)
+		if _, err := storage.Migrate(db); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, describeSchema(t, NewTestDB(t)), describeSchema(t, db))
+	})
+	t.Run("does nothing when schema is up to date", func(t *testing.T) {
+		db := newEmptyTestDB(t)
+		if _, err := storage.Migrate(db); err != nil {
+			t.Fatal(err)
+		}
+		n, err := storage.Migrate(db)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, n)
+		}
+	})
+	t.Run("can upgrade database created from baseline schema", func(t *testing.T) {
+		db := newEmptyTestDB(t)
+		ddl, err := os.ReadFile(filepath.Join("testdata", "schema_baseline.sql"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(ddl)); err != nil {
+			t.Fatal(err)
+		}
+		dueAt := time.Now().Add(time.Hour).UTC()
+		_, err = db.Exec(`
+			INSERT INTO bookmarks (
+				author_id, channel_id, content, created_at, due_at, guild_id, message_id, timestamp, updated_at, user_id
+			) VALUES ('author', 'channel', 'Release is on Friday', ?, ?, 'guild', 'message', ?, ?, 'user')`,
+			time.Now().UTC(), dueAt, time.Now().UTC(), time.Now().UTC(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := storage.SchemaVersion(db)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, v)
+		}
+		n, err := storage.Migrate(db)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, storage.LatestSchemaVersion(), n)
+		assert.Equal(t, describeSchema(t, NewTestDB(t)), describeSchema(t, db))
+		st := storage.New(db, db)
+		bookmarks, err := st.ListBookmarksForUser("user")
+		if !assert.NoError(t, err) || !assert.Len(t, bookmarks, 1) {
+			return
+		}
+		bm := bookmarks[0]
+		assert.Equal(t, "Release is on Friday", bm.Content)
+		assert.True(t, dueAt.Equal(bm.RecurrenceAnchor.Time))
+		assert.NoError(t, st.SetRecurrence(bm.ID, "user", "weekly"))
+		assert.NoError(t, st.SetNote(bm.ID, "user", "changelog"))
+		assert.NoError(t, st.AddTag(bm.ID, "user", "work"))
+		results, err := st.SearchBookmarksForUser("user", "friday", 10)
+		if assert.NoError(t, err) {
+			assert.Len(t, results, 1)
+		}
+	})
+	t.Run("should reject database with newer schema", func(t *testing.T) {
+		db := newEmptyTestDB(t)
+		if _, err := storage.Migrate(db); err != nil {
+			t.Fatal(err)
+		}
+		_, err := db.Exec(
+			"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'future', ?)",
+			storage.LatestSchemaVersion()+1, time.Now(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = storage.Migrate(db)
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrationStatus(t *testing.T) {
+	db := newEmptyTestDB(t)
+	mm, err := storage.MigrationStatus(db)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, mm, storage.LatestSchemaVersion())
+	for i, m := range mm {
+		assert.Equal(t, i+1, m.Version)
+		assert.False(t, m.IsApplied())
+	}
+	assert.Equal(t, "baseline", mm[0].Name)
+	if _, err := storage.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	mm, err = storage.MigrationStatus(db)
+	if assert.NoError(t, err) {
+		for _, m := range mm {
+			assert.True(t, m.IsApplied())
+		}
+	}
+}
+
+// newEmptyTestDB returns a new in-memory database without schema.
+func newEmptyTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:?_fk=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) // every connection has its own in-memory database
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+// describeSchema returns a description of the tables, columns, indexes and triggers of a database.
+// The order of columns is ignored, since migrations can only add columns at the end.
+func describeSchema(t *testing.T, db *sql.DB) map[string][]string {
+	rows, err := db.Query(`
+		SELECT type, name FROM sqlite_master
+		WHERE name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'
+		ORDER BY type, name
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	type object struct{ typ, name string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.typ, &o.name); err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, o)
+	}
+	schema := make(map[string][]string)
+	for _, o := range objects {
+		if o.typ != "table" {
+			schema[o.typ] = append(schema[o.typ], o.name)
+			continue
+		}
+		cols, err := db.Query(fmt.Sprintf("SELECT name, type, \"notnull\", COALESCE(dflt_value, ''), pk FROM pragma_table_info('%s') ORDER BY name", o.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for cols.Next() {
+			var name, typ, dflt string
+			var notNull, pk int
+			if err := cols.Scan(&name, &typ, &notNull, &dflt, &pk); err != nil {
+				t.Fatal(err)
+			}
+			schema[o.name] = append(schema[o.name], fmt.Sprintf("%s %s notnull=%d default=%s pk=%d", name, typ, notNull, dflt, pk))
+		}
+		cols.Close()
+	}
+	return schema
+}
@@ -0,0 +1,11 @@
+CREATE TABLE user_settings (
+  user_id TEXT PRIMARY KEY NOT NULL,
+  created_at DATETIME NOT NULL,
+  default_reminder INTEGER NOT NULL,
+  list_page_size INTEGER NOT NULL,
+  locale TEXT NOT NULL,
+  quiet_hours_end INTEGER NOT NULL,
+  quiet_hours_start INTEGER NOT NULL,
+  timezone TEXT NOT NULL,
+  updated_at DATETIME NOT NULL
+);
@@ -0,0 +1,9 @@
+ALTER TABLE bookmarks
+ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE bookmarks
+ADD COLUMN recurrence_anchor DATETIME;
+
+UPDATE bookmarks
+SET
+  recurrence_anchor = due_at;
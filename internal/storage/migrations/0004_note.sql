@@ -0,0 +1,2 @@
+ALTER TABLE bookmarks
+ADD COLUMN note TEXT;
@@ -0,0 +1,37 @@
+CREATE VIRTUAL TABLE bookmarks_fts USING fts5 (content, note, content='bookmarks', content_rowid='id');
+
+CREATE TRIGGER bookmarks_fts_ai AFTER INSERT ON bookmarks BEGIN
+INSERT INTO
+  bookmarks_fts (rowid, content, note)
+VALUES
+  (new.id, new.content, new.note);
+
+END;
+
+CREATE TRIGGER bookmarks_fts_ad AFTER DELETE ON bookmarks BEGIN
+INSERT INTO
+  bookmarks_fts (bookmarks_fts, rowid, content, note)
+VALUES
+  ('delete', old.id, old.content, old.note);
+
+END;
+
+CREATE TRIGGER bookmarks_fts_au AFTER
+UPDATE OF content, note ON bookmarks BEGIN
+INSERT INTO
+  bookmarks_fts (bookmarks_fts, rowid, content, note)
+VALUES
+  ('delete', old.id, old.content, old.note);
+
+INSERT INTO
+  bookmarks_fts (rowid, content, note)
+VALUES
+  (new.id, new.content, new.note);
+
+END;
+
+-- index existing bookmarks
+INSERT INTO
+  bookmarks_fts (bookmarks_fts)
+VALUES
+  ('rebuild');
@@ -0,0 +1,14 @@
+CREATE TABLE tags (
+  id INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE bookmark_tags (
+  bookmark_id INTEGER NOT NULL REFERENCES bookmarks (id) ON DELETE CASCADE,
+  tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+  PRIMARY KEY (bookmark_id, tag_id)
+);
+
+CREATE INDEX bookmark_tags_idx_1 ON bookmark_tags (tag_id);
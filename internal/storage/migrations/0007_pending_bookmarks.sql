@@ -0,0 +1,14 @@
+CREATE TABLE pending_bookmarks (
+  id INTEGER PRIMARY KEY,
+  author_id TEXT NOT NULL,
+  channel_id TEXT NOT NULL,
+  content TEXT NOT NULL,
+  created_at DATETIME NOT NULL,
+  expires_at DATETIME NOT NULL,
+  guild_id TEXT NOT NULL,
+  message_id TEXT NOT NULL,
+  timestamp DATETIME NOT NULL,
+  user_id TEXT NOT NULL
+);
+
+CREATE INDEX pending_bookmarks_idx_1 ON pending_bookmarks (expires_at);
@@ -0,0 +1,14 @@
+CREATE TABLE reminder_deliveries (
+  id INTEGER PRIMARY KEY,
+  attempts INTEGER NOT NULL,
+  bookmark_id INTEGER NOT NULL REFERENCES bookmarks (id) ON DELETE CASCADE,
+  created_at DATETIME NOT NULL,
+  due_at DATETIME NOT NULL,
+  error TEXT NOT NULL,
+  lease_expires_at DATETIME,
+  lease_owner TEXT NOT NULL,
+  next_attempt_at DATETIME,
+  status TEXT NOT NULL,
+  updated_at DATETIME NOT NULL,
+  UNIQUE (bookmark_id, due_at)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE user_settings
+ADD COLUMN digest_hour INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE user_settings
+ADD COLUMN digest_mode TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE user_settings
+ADD COLUMN digest_weekday INTEGER NOT NULL DEFAULT 0;
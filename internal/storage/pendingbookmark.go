@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// PendingBookmarkTimeout is how long a pending bookmark waits for the user to choose a reminder.
+const PendingBookmarkTimeout = time.Hour
+
+// CreatePendingBookmark stores a bookmark which is created later, e.g. after the user has chosen a reminder.
+// It returns the ID of the pending bookmark.
+func (st *Storage) CreatePendingBookmark(arg UpdateOrCreateBookmarkParams) (int64, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("CreatePendingBookmark: %+v: %w", arg, err)
+	}
+	if !arg.isValid() {
+		return 0, wrapErr(fmt.Errorf("invalid arg"))
+	}
+	now := time.Now().UTC()
+	id, err := st.qRW.CreatePendingBookmark(context.Background(), queries.CreatePendingBookmarkParams{
+		AuthorID:  arg.AuthorID,
+		ChannelID: arg.ChannelID,
+		Content:   arg.Content,
+		CreatedAt: now,
+		ExpiresAt: now.Add(PendingBookmarkTimeout),
+		GuildID:   arg.GuildID,
+		MessageID: arg.MessageID,
+		Timestamp: arg.Timestamp.UTC(),
+		UserID:    arg.UserID,
+	})
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+	return id, nil
+}
+
+// GetPendingBookmark returns a pending bookmark of a user as params for creating the bookmark.
+// Returns [sql.ErrNoRows] when the pending bookmark does not exist or has expired.
+func (st *Storage) GetPendingBookmark(id int64, userID string) (UpdateOrCreateBookmarkParams, error) {
+	o, err := st.qRO.GetPendingBookmark(context.Background(), queries.GetPendingBookmarkParams{
+		ID:     id,
+		Now:    time.Now().UTC(),
+		UserID: userID,
+	})
+	if err != nil {
+		return UpdateOrCreateBookmarkParams{}, fmt.Errorf("GetPendingBookmark: ID %d: %w", id, err)
+	}
+	arg := UpdateOrCreateBookmarkParams{
+		AuthorID:  o.AuthorID,
+		ChannelID: o.ChannelID,
+		Content:   o.Content,
+		GuildID:   o.GuildID,
+		MessageID: o.MessageID,
+		Timestamp: o.Timestamp,
+		UserID:    o.UserID,
+	}
+	return arg, nil
+}
+
+// DeletePendingBookmark deletes a pending bookmark.
+func (st *Storage) DeletePendingBookmark(id int64) error {
+	if err := st.qRW.DeletePendingBookmark(context.Background(), id); err != nil {
+		return fmt.Errorf("DeletePendingBookmark: ID %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteExpiredPendingBookmarks deletes all expired pending bookmarks and returns how many were deleted.
+func (st *Storage) DeleteExpiredPendingBookmarks() (int, error) {
+	n, err := st.qRW.DeleteExpiredPendingBookmarks(context.Background(), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("DeleteExpiredPendingBookmarks: %w", err)
+	}
+	if n > 0 {
+		slog.Info("Expired pending bookmarks deleted", "count", n)
+	}
+	return int(n), nil
+}
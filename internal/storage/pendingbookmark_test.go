@@ -0,0 +1,98 @@
+package storage_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestPendingBookmark(t *testing.T) {
+	db := NewTestDB(t)
+	st := storage.New(db, db)
+	arg := storage.UpdateOrCreateBookmarkParams{
+		AuthorID:  "author",
+		ChannelID: "channel",
+		Content:   "content",
+		GuildID:   "guild",
+		MessageID: "message",
+		Timestamp: time.Date(2025, 10, 17, 9, 0, 0, 0, time.UTC),
+		UserID:    "user",
+	}
+	t.Run("can create and get pending bookmark", func(t *testing.T) {
+		id, err := st.CreatePendingBookmark(arg)
+		if assert.NoError(t, err) {
+			got, err := st.GetPendingBookmark(id, "user")
+			if assert.NoError(t, err) {
+				assert.Equal(t, arg, got)
+			}
+		}
+	})
+	t.Run("should not return pending bookmark of another user", func(t *testing.T) {
+		id, err := st.CreatePendingBookmark(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = st.GetPendingBookmark(id, "other-user")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+	t.Run("can delete pending bookmark", func(t *testing.T) {
+		id, err := st.CreatePendingBookmark(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = st.DeletePendingBookmark(id)
+		if assert.NoError(t, err) {
+			_, err = st.GetPendingBookmark(id, "user")
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		}
+	})
+	t.Run("should not create invalid pending bookmark", func(t *testing.T) {
+		_, err := st.CreatePendingBookmark(storage.UpdateOrCreateBookmarkParams{UserID: "user"})
+		assert.Error(t, err)
+	})
+	t.Run("should not return expired pending bookmark", func(t *testing.T) {
+		id, err := st.CreatePendingBookmark(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expire(t, db, id)
+		_, err = st.GetPendingBookmark(id, "user")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+	t.Run("can delete expired pending bookmarks", func(t *testing.T) {
+		if _, err := st.DeleteExpiredPendingBookmarks(); err != nil {
+			t.Fatal(err)
+		}
+		id1, err := st.CreatePendingBookmark(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expire(t, db, id1)
+		id2, err := st.CreatePendingBookmark(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := st.DeleteExpiredPendingBookmarks()
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, n)
+			_, err := st.GetPendingBookmark(id2, "user")
+			assert.NoError(t, err)
+		}
+	})
+}
+
+// expire lets a pending bookmark expire.
+func expire(t *testing.T, db *sql.DB, id int64) {
+	_, err := db.Exec(
+		"UPDATE pending_bookmarks SET expires_at = ? WHERE id = ?",
+		time.Now().UTC().Add(-time.Minute),
+		id,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
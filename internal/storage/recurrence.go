@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of recurrence rules
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// Max number of candidates evaluated when searching the next occurrence.
+const maxOccurrenceCandidates = 10_000
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// RecurrenceRule represents a rule for recurring reminders.
+// It supports a subset of RFC 5545 RRULEs with FREQ, INTERVAL and BYDAY.
+type RecurrenceRule struct {
+	Freq     string
+	Interval int
+	// Weekdays the reminder is due on. Only used for weekly rules.
+	// Defaults to the weekday of the previous occurrence when empty.
+	Weekdays []time.Weekday
+}
+
+// ParseRecurrenceRule parses a recurrence rule from a string.
+//
+// Supported are the shortcuts "daily", "weekdays", "weekly", "monthly",
+// "weekly:mo,we" and RRULEs like "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"
+// with an optional "RRULE:" prefix.
+func ParseRecurrenceRule(s string) (RecurrenceRule, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("ParseRecurrenceRule: %q: %w", s, err)
+	}
+	x := strings.ToUpper(strings.TrimSpace(s))
+	x = strings.TrimPrefix(x, "RRULE:")
+	var r RecurrenceRule
+	switch x {
+	case FreqDaily:
+		r = RecurrenceRule{Freq: FreqDaily, Interval: 1}
+	case "WEEKDAYS":
+		r = RecurrenceRule{Freq: FreqWeekly, Interval: 1, Weekdays: []time.Weekday{
+			time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday,
+		}}
+	case FreqWeekly:
+		r = RecurrenceRule{Freq: FreqWeekly, Interval: 1}
+	case FreqMonthly:
+		r = RecurrenceRule{Freq: FreqMonthly, Interval: 1}
+	default:
+		r.Interval = 1
+		if days, found := strings.CutPrefix(x, "WEEKLY:"); found {
+			x = "FREQ=WEEKLY;BYDAY=" + days
+		}
+		for part := range strings.SplitSeq(x, ";") {
+			k, v, found := strings.Cut(part, "=")
+			if !found {
+				return RecurrenceRule{}, wrapErr(fmt.Errorf("invalid part: %s", part))
+			}
+			switch k {
+			case "FREQ":
+				r.Freq = v
+			case "INTERVAL":
+				n, err := strconv.Atoi(v)
+				if err != nil {
+					return RecurrenceRule{}, wrapErr(err)
+				}
+				r.Interval = n
+			case "BYDAY":
+				for code := range strings.SplitSeq(v, ",") {
+					i := slices.Index(weekdayCodes, strings.TrimSpace(code))
+					if i < 0 {
+						return RecurrenceRule{}, wrapErr(fmt.Errorf("invalid weekday: %s", code))
+					}
+					r.Weekdays = append(r.Weekdays, time.Weekday(i))
+				}
+			default:
+				return RecurrenceRule{}, wrapErr(fmt.Errorf("unsupported part: %s", k))
+			}
+		}
+	}
+	if err := r.validate(); err != nil {
+		return RecurrenceRule{}, wrapErr(err)
+	}
+	slices.Sort(r.Weekdays)
+	r.Weekdays = slices.Compact(r.Weekdays)
+	return r, nil
+}
+
+func (r RecurrenceRule) validate() error {
+	switch r.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly:
+	default:
+		return fmt.Errorf("unsupported frequency: %s", r.Freq)
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("invalid interval: %d", r.Interval)
+	}
+	if len(r.Weekdays) > 0 && r.Freq != FreqWeekly {
+		return fmt.Errorf("weekdays are only supported for weekly rules")
+	}
+	return nil
+}
+
+// String returns the rule as RRULE, e.g. "FREQ=WEEKLY;BYDAY=MO,WE".
+func (r RecurrenceRule) String() string {
+	s := "FREQ=" + r.Freq
+	if r.Interval > 1 {
+		s += ";INTERVAL=" + strconv.Itoa(r.Interval)
+	}
+	if len(r.Weekdays) > 0 {
+		codes := make([]string, 0, len(r.Weekdays))
+		for _, d := range r.Weekdays {
+			codes = append(codes, weekdayCodes[d])
+		}
+		s += ";BYDAY=" + strings.Join(codes, ",")
+	}
+	return s
+}
+
+// Description returns a human readable description of the rule, e.g. "weekly on Mon, Wed".
+func (r RecurrenceRule) Description() string {
+	var s string
+	units := map[string]string{FreqDaily: "day", FreqWeekly: "week", FreqMonthly: "month"}
+	if r.Interval > 1 {
+		s = fmt.Sprintf("every %d %ss", r.Interval, units[r.Freq])
+	} else {
+		s = strings.ToLower(r.Freq)
+	}
+	if len(r.Weekdays) == 5 && !slices.Contains(r.Weekdays, time.Saturday) && !slices.Contains(r.Weekdays, time.Sunday) && r.Interval == 1 {
+		return "every weekday"
+	}
+	if len(r.Weekdays) > 0 {
+		names := make([]string, 0, len(r.Weekdays))
+		for _, d := range r.Weekdays {
+			names = append(names, d.String()[:3])
+		}
+		s += " on " + strings.Join(names, ", ")
+	}
+	return s
+}
+
+// Next returns the first occurrence of the rule after the time after.
+//
+// Occurrences keep the wall clock time of the previous occurrence prev in the location loc,
+// so that a reminder due at 09:00 stays at 09:00 local time across DST changes.
+// Monthly occurrences on days missing in a month fall on the last day of that month.
+func (r RecurrenceRule) Next(prev, after time.Time, loc *time.Location) (time.Time, error) {
+	if err := r.validate(); err != nil {
+		return time.Time{}, err
+	}
+	p := prev.In(loc)
+	at := func(year int, month time.Month, day int) time.Time {
+		t := time.Date(year, month, day, p.Hour(), p.Minute(), p.Second(), 0, loc)
+		if h := t.Hour(); h < p.Hour() {
+			// wall clock time was skipped by a DST change, so we move it forward
+			t = t.Add(time.Duration(p.Hour()-h) * time.Hour)
+		}
+		return t
+	}
+	switch r.Freq {
+	case FreqDaily:
+		for i := 1; i <= maxOccurrenceCandidates; i++ {
+			t := at(p.Year(), p.Month(), p.Day()+i*r.Interval)
+			if t.After(after) {
+				return t, nil
+			}
+		}
+	case FreqWeekly:
+		weekdays := r.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{p.Weekday()}
+		}
+		// weeks start on Monday
+		weekStart := p.Day() - (int(p.Weekday())+6)%7
+		for i := 1; i <= maxOccurrenceCandidates; i++ {
+			day := p.Day() + i
+			week := (day - weekStart) / 7
+			if week%r.Interval != 0 {
+				continue
+			}
+			t := at(p.Year(), p.Month(), day)
+			if slices.Contains(weekdays, t.Weekday()) && t.After(after) {
+				return t, nil
+			}
+		}
+	case FreqMonthly:
+		for i := 1; i <= maxOccurrenceCandidates; i++ {
+			first := time.Date(p.Year(), p.Month()+time.Month(i*r.Interval), 1, 0, 0, 0, 0, loc)
+			daysInMonth := first.AddDate(0, 1, -1).Day()
+			t := at(first.Year(), first.Month(), min(p.Day(), daysInMonth))
+			if t.After(after) {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("no occurrence found for %s after %s", r, after)
+}
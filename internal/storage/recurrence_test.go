@@ -0,0 +1,205 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"daily", "FREQ=DAILY"},
+		{"Weekdays", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{"weekly", "FREQ=WEEKLY"},
+		{"monthly", "FREQ=MONTHLY"},
+		{"weekly:we,mo", "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR"},
+		{"RRULE:FREQ=DAILY;INTERVAL=3", "FREQ=DAILY;INTERVAL=3"},
+		{"freq=monthly;interval=1", "FREQ=MONTHLY"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			r, err := storage.ParseRecurrenceRule(tc.input)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, r.String())
+			}
+		})
+	}
+	for _, input := range []string{
+		"",
+		"hourly",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;COUNT=3",
+	} {
+		t.Run("invalid: "+input, func(t *testing.T) {
+			_, err := storage.ParseRecurrenceRule(input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRecurrenceRuleDescription(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"daily", "daily"},
+		{"weekdays", "every weekday"},
+		{"weekly:mo,we", "weekly on Mon, Wed"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", "every 2 weeks on Fri"},
+		{"monthly", "monthly"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			r, err := storage.ParseRecurrenceRule(tc.input)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, r.Description())
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		rule  string
+		prev  time.Time
+		after time.Time
+		loc   *time.Location
+		want  time.Time
+	}{
+		{
+			name:  "daily",
+			rule:  "daily",
+			prev:  time.Date(2025, 10, 17, 9, 0, 0, 0, berlin),
+			after: time.Date(2025, 10, 17, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 10, 18, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:  "daily keeps local time when DST ends",
+			rule:  "daily",
+			prev:  time.Date(2025, 10, 25, 9, 0, 0, 0, berlin),
+			after: time.Date(2025, 10, 25, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 10, 26, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:  "daily keeps local time when DST starts",
+			rule:  "daily",
+			prev:  time.Date(2025, 3, 29, 9, 0, 0, 0, berlin),
+			after: time.Date(2025, 3, 29, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 3, 30, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:  "daily with time skipped by DST start",
+			rule:  "daily",
+			prev:  time.Date(2025, 3, 8, 2, 30, 0, 0, newYork),
+			after: time.Date(2025, 3, 8, 2, 30, 1, 0, newYork),
+			loc:   newYork,
+			want:  time.Date(2025, 3, 9, 3, 30, 0, 0, newYork),
+		},
+		{
+			name:  "daily uses timezone of user, not of previous time",
+			rule:  "daily",
+			prev:  time.Date(2025, 10, 25, 7, 0, 0, 0, time.UTC), // 09:00 in Berlin
+			after: time.Date(2025, 10, 25, 7, 0, 1, 0, time.UTC),
+			loc:   berlin,
+			want:  time.Date(2025, 10, 26, 8, 0, 0, 0, time.UTC), // 09:00 in Berlin after DST end
+		},
+		{
+			name:  "daily skips missed occurrences",
+			rule:  "daily",
+			prev:  time.Date(2025, 10, 10, 9, 0, 0, 0, berlin),
+			after: time.Date(2025, 10, 17, 12, 0, 0, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 10, 18, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:  "daily with interval",
+			rule:  "FREQ=DAILY;INTERVAL=3",
+			prev:  time.Date(2025, 10, 17, 9, 0, 0, 0, berlin),
+			after: time.Date(2025, 10, 17, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 10, 20, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:  "weekdays skips weekend",
+			rule:  "weekdays",
+			prev:  time.Date(2025, 10, 17, 9, 0, 0, 0, berlin), // Friday
+			after: time.Date(2025, 10, 17, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 10, 20, 9, 0, 0, 0, berlin), // Monday
+		},
+		{
+			name:  "weekly on same weekday across DST end",
+			rule:  "weekly",
+			prev:  time.Date(2025, 10, 21, 18, 30, 0, 0, berlin), // Tuesday
+			after: time.Date(2025, 10, 21, 18, 30, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 10, 28, 18, 30, 0, 0, berlin),
+		},
+		{
+			name:  "weekly on given days",
+			rule:  "weekly:mo,th",
+			prev:  time.Date(2025, 10, 20, 9, 0, 0, 0, berlin), // Monday
+			after: time.Date(2025, 10, 20, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 10, 23, 9, 0, 0, 0, berlin), // Thursday
+		},
+		{
+			name:  "bi-weekly on given days",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			prev:  time.Date(2025, 10, 23, 9, 0, 0, 0, berlin), // Thursday
+			after: time.Date(2025, 10, 23, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 11, 3, 9, 0, 0, 0, berlin), // Monday in two weeks
+		},
+		{
+			name:  "monthly across DST end",
+			rule:  "monthly",
+			prev:  time.Date(2025, 10, 15, 9, 0, 0, 0, berlin),
+			after: time.Date(2025, 10, 15, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 11, 15, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:  "monthly on last day for shorter months",
+			rule:  "monthly",
+			prev:  time.Date(2025, 1, 31, 9, 0, 0, 0, berlin),
+			after: time.Date(2025, 1, 31, 9, 0, 1, 0, berlin),
+			loc:   berlin,
+			want:  time.Date(2025, 2, 28, 9, 0, 0, 0, berlin),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := storage.ParseRecurrenceRule(tc.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.Next(tc.prev, tc.after, tc.loc)
+			if assert.NoError(t, err) {
+				assert.True(t, tc.want.Equal(got), "want: %s, got: %s", tc.want, got)
+			}
+		})
+	}
+}
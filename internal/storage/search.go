@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// Markers for highlighting matches in search snippets
+const (
+	SnippetMatchStart = "**"
+	SnippetMatchEnd   = "**"
+)
+
+// bookmarkColumns are the columns of the bookmarks table in the order of [queries.Bookmark].
+const bookmarkColumns = `bookmarks.id, bookmarks.author_id, bookmarks.channel_id, bookmarks.content,
+bookmarks.created_at, bookmarks.due_at, bookmarks.guild_id, bookmarks.message_id,
+bookmarks.note, bookmarks.recurrence, bookmarks.recurrence_anchor, bookmarks.timestamp,
+bookmarks.updated_at, bookmarks.user_id`
+
+// bookmarkScanDest returns the scan destinations for bookmarkColumns.
+func bookmarkScanDest(bm *queries.Bookmark) []any {
+	return []any{
+		&bm.ID,
+		&bm.AuthorID,
+		&bm.ChannelID,
+		&bm.Content,
+		&bm.CreatedAt,
+		&bm.DueAt,
+		&bm.GuildID,
+		&bm.MessageID,
+		&bm.Note,
+		&bm.Recurrence,
+		&bm.RecurrenceAnchor,
+		&bm.Timestamp,
+		&bm.UpdatedAt,
+		&bm.UserID,
+	}
+}
+
+// SearchResult is a bookmark found by a full-text search.
+type SearchResult struct {
+	Bookmark queries.Bookmark
+	// Excerpt of the content or note, whichever matched, with matches highlighted.
+	Snippet string
+	// Relevance of the result. Higher is better.
+	Score float64
+}
+
+// SearchBookmarksForUser searches the content and notes of a user's bookmarks
+// and returns up to limit results ordered by relevance.
+// All words must match. The last word also matches as prefix, e.g. "book" matches "bookmark".
+func (st *Storage) SearchBookmarksForUser(userID string, query string, limit int) ([]SearchResult, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("SearchBookmarksForUser: %s: %q: %w", userID, query, err)
+	}
+	match := makeMatchExpression(query)
+	if match == "" {
+		return []SearchResult{}, nil
+	}
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	// bm25 returns lower values for better matches
+	q := fmt.Sprintf(`
+SELECT %s,
+  snippet(bookmarks_fts, -1, ?, ?, '…', 20),
+  bm25(bookmarks_fts)
+FROM bookmarks_fts
+JOIN bookmarks ON bookmarks.id = bookmarks_fts.rowid
+WHERE bookmarks_fts MATCH ?
+AND bookmarks.user_id = ?
+ORDER BY bm25(bookmarks_fts), bookmarks.timestamp DESC
+LIMIT ?`, bookmarkColumns)
+	rows, err := st.dbRO.QueryContext(context.Background(), q, SnippetMatchStart, SnippetMatchEnd, match, userID, limit)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	defer rows.Close()
+	results := make([]SearchResult, 0)
+	for rows.Next() {
+		var r SearchResult
+		var rank float64
+		dest := append(bookmarkScanDest(&r.Bookmark), &r.Snippet, &rank)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, wrapErr(err)
+		}
+		r.Score = -rank
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapErr(err)
+	}
+	return results, nil
+}
+
+// makeMatchExpression returns a full-text match expression for a user query.
+// It only keeps letters and digits, so user input can not break the query syntax.
+// Returns an empty string when the query contains no words.
+func makeMatchExpression(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] += "*"
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,144 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestSearchBookmarks(t *testing.T) {
+	st := NewTestStorage(t)
+	userID := "abc123"
+	ids := func(rr []storage.SearchResult) []int64 {
+		var ids []int64
+		for _, r := range rr {
+			ids = append(ids, r.Bookmark.ID)
+		}
+		return ids
+	}
+	t.Run("can find bookmarks by words in content", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "The quick brown fox jumps over the lazy dog",
+			UserID:  userID,
+		})
+		CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "The quick red car",
+			UserID:  userID,
+		})
+		got, err := st.SearchBookmarksForUser(userID, "quick fox", 10)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []int64{bm1.ID}, ids(got))
+			assert.Contains(t, got[0].Snippet, "**fox**")
+		}
+	})
+	t.Run("can find bookmarks by words in note", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "Have a look at this",
+			UserID:  userID,
+		})
+		CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "Nothing to see here",
+			UserID:  userID,
+		})
+		if err := st.SetNote(bm1.ID, userID, "Recipe for pancakes"); err != nil {
+			t.Fatal(err)
+		}
+		got, err := st.SearchBookmarksForUser(userID, "pancakes", 10)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []int64{bm1.ID}, ids(got))
+			assert.Contains(t, got[0].Snippet, "**pancakes**")
+		}
+		if err := st.SetNote(bm1.ID, userID, "Recipe for waffles"); err != nil {
+			t.Fatal(err)
+		}
+		got, err = st.SearchBookmarksForUser(userID, "pancakes", 10)
+		if assert.NoError(t, err) {
+			assert.Empty(t, got)
+		}
+	})
+	t.Run("matches last word as prefix", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "Meeting about the new bookmarker release",
+			UserID:  userID,
+		})
+		got, err := st.SearchBookmarksForUser(userID, "Book", 10)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []int64{bm1.ID}, ids(got))
+		}
+	})
+	t.Run("returns results ranked by relevance", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "release notes",
+			UserID:  userID,
+		})
+		bm2 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "release release release",
+			UserID:  userID,
+		})
+		got, err := st.SearchBookmarksForUser(userID, "release", 10)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []int64{bm2.ID, bm1.ID}, ids(got))
+			assert.Greater(t, got[0].Score, got[1].Score)
+		}
+	})
+	t.Run("respects limit", func(t *testing.T) {
+		ClearStorage(t, st)
+		for range 3 {
+			CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+				Content: "alpha",
+				UserID:  userID,
+			})
+		}
+		got, err := st.SearchBookmarksForUser(userID, "alpha", 2)
+		if assert.NoError(t, err) {
+			assert.Len(t, got, 2)
+		}
+	})
+	t.Run("does not return bookmarks of other users", func(t *testing.T) {
+		ClearStorage(t, st)
+		CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "secret plans",
+		})
+		got, err := st.SearchBookmarksForUser(userID, "secret", 10)
+		if assert.NoError(t, err) {
+			assert.Empty(t, got)
+		}
+	})
+	t.Run("does not find deleted bookmarks", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "temporary note",
+			UserID:  userID,
+		})
+		if err := st.DeleteBookmark(bm.ID, userID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := st.SearchBookmarksForUser(userID, "temporary", 10)
+		if assert.NoError(t, err) {
+			assert.Empty(t, got)
+		}
+	})
+	t.Run("ignores query syntax in user input", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{
+			Content: "Don't panic",
+			UserID:  userID,
+		})
+		for _, q := range []string{`"don't`, "(panic", "panic)", "-panic", "pan*"} {
+			got, err := st.SearchBookmarksForUser(userID, q, 10)
+			if assert.NoError(t, err, q) {
+				assert.Equal(t, []int64{bm1.ID}, ids(got), q)
+			}
+		}
+		got, err := st.SearchBookmarksForUser(userID, `"*"`, 10)
+		if assert.NoError(t, err) {
+			assert.Empty(t, got)
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
@@ -17,6 +18,8 @@ type Storage struct {
 	dbRW *sql.DB
 	qRO  *queries.Queries
 	qRW  *queries.Queries
+
+	dueAtChanged func(id int64, dueAt time.Time)
 }
 
 // New returns a new storage object.
@@ -30,8 +33,35 @@ func New(dbRW *sql.DB, dbRO *sql.DB) *Storage {
 	return r
 }
 
-// InitDB initializes the database and returns it.
+// OnDueAtChanged registers a function which is called after the due time of a reminder has changed.
+// dueAt is zero when the reminder was removed.
+// It must be registered before the storage is used concurrently.
+func (st *Storage) OnDueAtChanged(f func(id int64, dueAt time.Time)) {
+	st.dueAtChanged = f
+}
+
+func (st *Storage) notifyDueAtChanged(id int64, dueAt time.Time) {
+	if st.dueAtChanged != nil {
+		st.dueAtChanged(id, dueAt)
+	}
+}
+
+// InitDB opens the database, upgrades its schema to the latest version and returns it.
 func InitDB(dsn string) (dbRW *sql.DB, dbRO *sql.DB, err error) {
+	dbRW, dbRO, err = OpenDB(dsn)
+	if err != nil {
+		return
+	}
+	if _, err = Migrate(dbRW); err != nil {
+		dbRW.Close()
+		dbRO.Close()
+		return nil, nil, err
+	}
+	return
+}
+
+// OpenDB opens the database without changing its schema and returns it.
+func OpenDB(dsn string) (dbRW *sql.DB, dbRO *sql.DB, err error) {
 	// create RW connection
 	dsn2 := sqliteDSN(dsn, false)
 	slog.Info("Creating RW connection to DB", "dsn", dsn2)
@@ -41,16 +71,13 @@ func InitDB(dsn string) (dbRW *sql.DB, dbRO *sql.DB, err error) {
 		return
 	}
 	dbRW.SetMaxOpenConns(1)
-	if _, err = dbRW.Exec(queries.DDL()); err != nil {
-		return
-	}
 	// create RO connection
 	dsn2 = sqliteDSN(dsn, true)
 	slog.Info("Creating RO connection to DB", "DSN", dsn)
 	dbRO, err = sql.Open("sqlite3", dsn2)
 	if err != nil {
-		err = fmt.Errorf("open RO connection: %s: %w", dsn, err)
-		return
+		dbRW.Close()
+		return nil, nil, fmt.Errorf("open RO connection: %s: %w", dsn, err)
 	}
 	return
 }
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// Limits for tags
+const (
+	MaxTagLength       = 32
+	MaxTagsPerBookmark = 10
+)
+
+// ErrInvalidTag is returned when a tag name is invalid.
+var ErrInvalidTag = errors.New("invalid tag")
+
+// Tag is a tag of a user with the number of bookmarks tagged with it.
+type Tag struct {
+	Name      string
+	Bookmarks int
+}
+
+// NormalizeTag returns the normalized name of a tag, e.g. "#Read Later" becomes "read-later".
+// Tags can consist of letters, digits, dashes and underscores.
+// Returns [ErrInvalidTag] when the name is empty, too long or contains other characters.
+func NormalizeTag(name string) (string, error) {
+	s := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	s = strings.Join(strings.Fields(s), "-")
+	if s == "" || utf8.RuneCountInString(s) > MaxTagLength {
+		return "", fmt.Errorf("%q: %w", name, ErrInvalidTag)
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "", fmt.Errorf("%q: %w", name, ErrInvalidTag)
+		}
+	}
+	return s, nil
+}
+
+// ParseTags returns the normalized tags from a list separated by commas, e.g. "work, read later".
+// Duplicates are removed.
+func ParseTags(s string) ([]string, error) {
+	tags := make([]string, 0)
+	for x := range strings.SplitSeq(s, ",") {
+		if strings.TrimSpace(x) == "" {
+			continue
+		}
+		t, err := NormalizeTag(x)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags), nil
+}
+
+// AddTag adds a tag to a bookmark owned by a user. The tag is created when it does not exist.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) AddTag(id int64, userID string, name string) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("AddTag: ID %d: %w", id, err)
+	}
+	name, err := NormalizeTag(name)
+	if err != nil {
+		return wrapErr(err)
+	}
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	if _, err := qtx.GetBookmarkForUser(ctx, queries.GetBookmarkForUserParams{ID: id, UserID: userID}); err != nil {
+		return wrapErr(err)
+	}
+	tags, err := qtx.ListTagsForBookmark(ctx, id)
+	if err != nil {
+		return wrapErr(err)
+	}
+	if !slices.Contains(tags, name) && len(tags) >= MaxTagsPerBookmark {
+		return wrapErr(fmt.Errorf("more than %d tags: %w", MaxTagsPerBookmark, ErrInvalidTag))
+	}
+	if err := addTag(ctx, qtx, id, userID, name); err != nil {
+		return wrapErr(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return wrapErr(err)
+	}
+	slog.Info("Tag added", "id", id, "user", userID, "tag", name)
+	return nil
+}
+
+func addTag(ctx context.Context, qtx *queries.Queries, id int64, userID string, name string) error {
+	err := qtx.CreateTag(ctx, queries.CreateTagParams{Name: name, UserID: userID})
+	if err != nil {
+		return err
+	}
+	tagID, err := qtx.GetTagID(ctx, queries.GetTagIDParams{Name: name, UserID: userID})
+	if err != nil {
+		return err
+	}
+	return qtx.AddBookmarkTag(ctx, queries.AddBookmarkTagParams{BookmarkID: id, TagID: tagID})
+}
+
+// RemoveTag removes a tag from a bookmark owned by a user.
+// Tags no longer used by any bookmark are deleted.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID and tag.
+func (st *Storage) RemoveTag(id int64, userID string, name string) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("RemoveTag: ID %d: %w", id, err)
+	}
+	name, err := NormalizeTag(name)
+	if err != nil {
+		return wrapErr(err)
+	}
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	n, err := qtx.DeleteBookmarkTag(ctx, queries.DeleteBookmarkTagParams{
+		BookmarkID: id,
+		Name:       name,
+		UserID:     userID,
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	if n == 0 {
+		return wrapErr(sql.ErrNoRows)
+	}
+	if err := qtx.DeleteUnusedTags(ctx, userID); err != nil {
+		return wrapErr(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return wrapErr(err)
+	}
+	slog.Info("Tag removed", "id", id, "user", userID, "tag", name)
+	return nil
+}
+
+// SetTags replaces the tags of a bookmark owned by a user.
+// An empty list removes all tags.
+// Returns [sql.ErrNoRows] when the user does not own a bookmark with that ID.
+func (st *Storage) SetTags(id int64, userID string, names []string) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("SetTags: ID %d: %w", id, err)
+	}
+	tags := make([]string, 0, len(names))
+	for _, n := range names {
+		t, err := NormalizeTag(n)
+		if err != nil {
+			return wrapErr(err)
+		}
+		tags = append(tags, t)
+	}
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+	if len(tags) > MaxTagsPerBookmark {
+		return wrapErr(fmt.Errorf("more than %d tags: %w", MaxTagsPerBookmark, ErrInvalidTag))
+	}
+	ctx := context.Background()
+	tx, err := st.dbRW.Begin()
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer tx.Rollback()
+	qtx := st.qRW.WithTx(tx)
+	if _, err := qtx.GetBookmarkForUser(ctx, queries.GetBookmarkForUserParams{ID: id, UserID: userID}); err != nil {
+		return wrapErr(err)
+	}
+	if err := qtx.DeleteBookmarkTags(ctx, id); err != nil {
+		return wrapErr(err)
+	}
+	for _, t := range tags {
+		if err := addTag(ctx, qtx, id, userID, t); err != nil {
+			return wrapErr(err)
+		}
+	}
+	if err := qtx.DeleteUnusedTags(ctx, userID); err != nil {
+		return wrapErr(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return wrapErr(err)
+	}
+	slog.Info("Tags set", "id", id, "user", userID, "tags", tags)
+	return nil
+}
+
+// ListTagsForBookmark returns the tags of a bookmark in alphabetical order.
+func (st *Storage) ListTagsForBookmark(id int64) ([]string, error) {
+	tags, err := st.qRO.ListTagsForBookmark(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("ListTagsForBookmark: ID %d: %w", id, err)
+	}
+	return tags, nil
+}
+
+// ListTagsForUser returns the tags of a user in alphabetical order.
+func (st *Storage) ListTagsForUser(userID string) ([]Tag, error) {
+	rows, err := st.qRO.ListTagsForUser(context.Background(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("ListTagsForUser: %s: %w", userID, err)
+	}
+	tags := make([]Tag, 0, len(rows))
+	for _, r := range rows {
+		tags = append(tags, Tag{Name: r.Name, Bookmarks: int(r.Bookmarks)})
+	}
+	return tags, nil
+}
@@ -0,0 +1,222 @@
+package storage_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"work", "work"},
+		{" #Work ", "work"},
+		{"Read  Later", "read-later"},
+		{"to_do-2", "to_do-2"},
+		{"größe", "größe"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := storage.NormalizeTag(tc.input)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+	for _, input := range []string{"", " # ", "a,b", "work!", "abcdefghijklmnopqrstuvwxyz0123456"} {
+		t.Run("invalid: "+input, func(t *testing.T) {
+			_, err := storage.NormalizeTag(input)
+			assert.ErrorIs(t, err, storage.ErrInvalidTag)
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	got, err := storage.ParseTags("work, Read Later,,#work ")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"read-later", "work"}, got)
+	}
+	got, err = storage.ParseTags(" ")
+	if assert.NoError(t, err) {
+		assert.Empty(t, got)
+	}
+	_, err = storage.ParseTags("work, ?")
+	assert.ErrorIs(t, err, storage.ErrInvalidTag)
+}
+
+func TestTags(t *testing.T) {
+	db := NewTestDB(t)
+	st := storage.New(db, db)
+	t.Run("can add tags to bookmark", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		if err := st.AddTag(bm.ID, bm.UserID, "Work"); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.AddTag(bm.ID, bm.UserID, "alpha"); err != nil {
+			t.Fatal(err)
+		}
+		err := st.AddTag(bm.ID, bm.UserID, "work")
+		if assert.NoError(t, err) {
+			got, err := st.ListTagsForBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{"alpha", "work"}, got)
+			}
+		}
+	})
+	t.Run("should not add tag to bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		err := st.AddTag(bm.ID, "other-user", "work")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		got, err := st.ListTagsForUser("other-user")
+		if assert.NoError(t, err) {
+			assert.Empty(t, got)
+		}
+	})
+	t.Run("should not add more than max tags", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		for i := range storage.MaxTagsPerBookmark {
+			if err := st.AddTag(bm.ID, bm.UserID, string(rune('a'+i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := st.AddTag(bm.ID, bm.UserID, "z")
+		assert.ErrorIs(t, err, storage.ErrInvalidTag)
+	})
+	t.Run("can remove tag from bookmark", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		if err := st.AddTag(bm.ID, bm.UserID, "work"); err != nil {
+			t.Fatal(err)
+		}
+		err := st.RemoveTag(bm.ID, bm.UserID, "work")
+		if assert.NoError(t, err) {
+			got, err := st.ListTagsForUser(bm.UserID)
+			if assert.NoError(t, err) {
+				assert.Empty(t, got)
+			}
+		}
+		err = st.RemoveTag(bm.ID, bm.UserID, "work")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+	t.Run("should not remove tag from bookmark of another user", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		if err := st.AddTag(bm.ID, bm.UserID, "work"); err != nil {
+			t.Fatal(err)
+		}
+		err := st.RemoveTag(bm.ID, "other-user", "work")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+	t.Run("can replace tags of bookmark", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		if err := st.AddTag(bm.ID, bm.UserID, "old"); err != nil {
+			t.Fatal(err)
+		}
+		err := st.SetTags(bm.ID, bm.UserID, []string{"b", "a", "B"})
+		if assert.NoError(t, err) {
+			got, err := st.ListTagsForBookmark(bm.ID)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{"a", "b"}, got)
+			}
+		}
+		err = st.SetTags(bm.ID, bm.UserID, nil)
+		if assert.NoError(t, err) {
+			got, err := st.ListTagsForUser(bm.UserID)
+			if assert.NoError(t, err) {
+				assert.Empty(t, got)
+			}
+		}
+	})
+	t.Run("can list tags for user with counts", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st)
+		bm2 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: bm1.UserID})
+		bm3 := CreateBookmark(t, st)
+		for _, x := range []struct {
+			id     int64
+			userID string
+			tag    string
+		}{
+			{bm1.ID, bm1.UserID, "work"},
+			{bm2.ID, bm2.UserID, "work"},
+			{bm2.ID, bm2.UserID, "fun"},
+			{bm3.ID, bm3.UserID, "other"},
+		} {
+			if err := st.AddTag(x.id, x.userID, x.tag); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := st.ListTagsForUser(bm1.UserID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []storage.Tag{{Name: "fun", Bookmarks: 1}, {Name: "work", Bookmarks: 2}}, got)
+		}
+	})
+	t.Run("can list bookmarks with tag", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st)
+		bm2 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: bm1.UserID})
+		CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: bm1.UserID})
+		if err := st.AddTag(bm2.ID, bm2.UserID, "work"); err != nil {
+			t.Fatal(err)
+		}
+		xx, total, err := st.ListBookmarksPageForUser(bm1.UserID, storage.BookmarkFilter{Tag: "#Work"}, storage.SortDefault, 0, 10)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, total)
+			if assert.Len(t, xx, 1) {
+				assert.Equal(t, bm2.ID, xx[0].ID)
+			}
+		}
+	})
+	t.Run("removes tags of deleted bookmarks", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm := CreateBookmark(t, st)
+		if err := st.AddTag(bm.ID, bm.UserID, "work"); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.DeleteBookmark(bm.ID, bm.UserID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := st.ListTagsForBookmark(bm.ID)
+		if assert.NoError(t, err) {
+			assert.Empty(t, got)
+		}
+		assert.Equal(t, 0, countTags(t, db, bm.UserID))
+	})
+	t.Run("keeps tags still used by other bookmarks when deleting a bookmark", func(t *testing.T) {
+		ClearStorage(t, st)
+		bm1 := CreateBookmark(t, st)
+		bm2 := CreateBookmark(t, st, storage.UpdateOrCreateBookmarkParams{UserID: bm1.UserID})
+		for _, id := range []int64{bm1.ID, bm2.ID} {
+			if err := st.AddTag(id, bm1.UserID, "work"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := st.DeleteBookmark(bm1.ID, bm1.UserID); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 1, countTags(t, db, bm1.UserID))
+		got, err := st.ListTagsForBookmark(bm2.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"work"}, got)
+		}
+	})
+}
+
+// countTags returns how many tags a user has, including tags no longer used by any bookmark.
+func countTags(t *testing.T, db *sql.DB, userID string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tags WHERE user_id = ?", userID).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
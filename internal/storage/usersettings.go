@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"example/discord-bookmarker/internal/queries"
+)
+
+// Defaults for user settings
+const (
+	DefaultListPageSize = 10
+	DefaultTimezone     = "UTC"
+	MaxListPageSize     = 10 // Discord allows max 10 embeds per message
+)
+
+// Digest modes
+const (
+	DigestOff    = ""
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// UserSettings represents the settings of a user.
+type UserSettings struct {
+	UserID string
+	// Reminder duration pre-selected when setting a reminder. Zero for none.
+	DefaultReminder time.Duration
+	// Hour of day in the user's timezone when the digest is sent.
+	DigestHour int
+	// Whether reminders are sent as a daily or weekly digest instead of one by one.
+	DigestMode string
+	// Day of the week when the weekly digest is sent.
+	DigestWeekday time.Weekday
+	// Number of bookmarks shown per page when listing bookmarks.
+	ListPageSize int
+	// Locale for formatting dates, e.g. "en-US". Empty for ISO format.
+	Locale string
+	// Quiet hours are the hours of day from start to end in the user's timezone.
+	// Start and end are equal when no quiet hours are set.
+	QuietHoursEnd   int
+	QuietHoursStart int
+	// IANA name of the user's timezone, e.g. "Europe/Berlin".
+	Timezone string
+}
+
+// DefaultUserSettings returns the settings for a user who has not changed any settings.
+func DefaultUserSettings(userID string) UserSettings {
+	return UserSettings{
+		UserID:       userID,
+		ListPageSize: DefaultListPageSize,
+		Timezone:     DefaultTimezone,
+	}
+}
+
+// HasQuietHours reports whether quiet hours are set.
+func (us UserSettings) HasQuietHours() bool {
+	return us.QuietHoursStart != us.QuietHoursEnd
+}
+
+// DeliveryTime returns when a reminder due at t is delivered to the user.
+// That is t or the end of the quiet hours when t is within them.
+func (us UserSettings) DeliveryTime(t time.Time) time.Time {
+	if !us.HasQuietHours() {
+		return t
+	}
+	lt := t.In(us.Location())
+	h := lt.Hour()
+	var isQuiet bool
+	if us.QuietHoursStart < us.QuietHoursEnd {
+		isQuiet = h >= us.QuietHoursStart && h < us.QuietHoursEnd
+	} else {
+		isQuiet = h >= us.QuietHoursStart || h < us.QuietHoursEnd
+	}
+	if !isQuiet {
+		return t
+	}
+	end := time.Date(lt.Year(), lt.Month(), lt.Day(), us.QuietHoursEnd, 0, 0, 0, lt.Location())
+	if !end.After(lt) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// HasDigest reports whether reminders are sent as digest.
+func (us UserSettings) HasDigest() bool {
+	return us.DigestMode != DigestOff
+}
+
+// NextDigest returns when the next digest is due after now.
+// Returns a zero time when the digest is off.
+func (us UserSettings) NextDigest(now time.Time) time.Time {
+	if !us.HasDigest() {
+		return time.Time{}
+	}
+	t := now.In(us.Location())
+	next := time.Date(t.Year(), t.Month(), t.Day(), us.DigestHour, 0, 0, 0, t.Location())
+	if us.DigestMode == DigestWeekly {
+		next = next.AddDate(0, 0, (int(us.DigestWeekday)-int(next.Weekday())+7)%7)
+	}
+	for !next.After(t) {
+		if us.DigestMode == DigestWeekly {
+			next = next.AddDate(0, 0, 7)
+		} else {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+	return next
+}
+
+// Location returns the location of the user's timezone.
+// Returns UTC when the timezone is invalid.
+func (us UserSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(us.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (us UserSettings) isValid() bool {
+	if us.UserID == "" || us.DefaultReminder < 0 {
+		return false
+	}
+	if us.ListPageSize < 1 || us.ListPageSize > MaxListPageSize {
+		return false
+	}
+	if us.QuietHoursStart < 0 || us.QuietHoursStart > 23 || us.QuietHoursEnd < 0 || us.QuietHoursEnd > 23 {
+		return false
+	}
+	if us.DigestMode != DigestOff && us.DigestMode != DigestDaily && us.DigestMode != DigestWeekly {
+		return false
+	}
+	if us.DigestHour < 0 || us.DigestHour > 23 || us.DigestWeekday < time.Sunday || us.DigestWeekday > time.Saturday {
+		return false
+	}
+	if _, err := time.LoadLocation(us.Timezone); err != nil || us.Timezone == "" {
+		return false
+	}
+	return true
+}
+
+func (st *Storage) DeleteUserSettings(userID string) error {
+	err := st.qRW.DeleteUserSettings(context.Background(), userID)
+	if err != nil {
+		return fmt.Errorf("DeleteUserSettings: %s: %w", userID, err)
+	}
+	slog.Info("User settings deleted", "user", userID)
+	return nil
+}
+
+// GetUserSettings returns the settings of a user.
+// Returns the default settings when the user has not changed any settings yet.
+func (st *Storage) GetUserSettings(userID string) (UserSettings, error) {
+	o, err := st.qRO.GetUserSettings(context.Background(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultUserSettings(userID), nil
+	} else if err != nil {
+		return UserSettings{}, fmt.Errorf("GetUserSettings: %s: %w", userID, err)
+	}
+	return newUserSettingsFromDB(o), nil
+}
+
+// ListDigestUserSettings returns the settings of all users who receive digests.
+func (st *Storage) ListDigestUserSettings() ([]UserSettings, error) {
+	rows, err := st.qRO.ListDigestUserSettings(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("ListDigestUserSettings: %w", err)
+	}
+	r := make([]UserSettings, 0, len(rows))
+	for _, o := range rows {
+		r = append(r, newUserSettingsFromDB(o))
+	}
+	return r, nil
+}
+
+func newUserSettingsFromDB(o queries.UserSetting) UserSettings {
+	return UserSettings{
+		UserID:          o.UserID,
+		DefaultReminder: time.Duration(o.DefaultReminder) * time.Second,
+		DigestHour:      int(o.DigestHour),
+		DigestMode:      o.DigestMode,
+		DigestWeekday:   time.Weekday(o.DigestWeekday),
+		ListPageSize:    int(o.ListPageSize),
+		Locale:          o.Locale,
+		QuietHoursEnd:   int(o.QuietHoursEnd),
+		QuietHoursStart: int(o.QuietHoursStart),
+		Timezone:        o.Timezone,
+	}
+}
+
+func (st *Storage) UpdateOrCreateUserSettings(arg UserSettings) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("UpdateOrCreateUserSettings: %+v: %w", arg, err)
+	}
+	if !arg.isValid() {
+		return wrapErr(fmt.Errorf("invalid arg"))
+	}
+	err := st.qRW.UpdateOrCreateUserSettings(context.Background(), queries.UpdateOrCreateUserSettingsParams{
+		UserID:          arg.UserID,
+		CreatedAt:       time.Now().UTC(),
+		DefaultReminder: int64(arg.DefaultReminder.Seconds()),
+		DigestHour:      int64(arg.DigestHour),
+		DigestMode:      arg.DigestMode,
+		DigestWeekday:   int64(arg.DigestWeekday),
+		ListPageSize:    int64(arg.ListPageSize),
+		Locale:          arg.Locale,
+		QuietHoursEnd:   int64(arg.QuietHoursEnd),
+		QuietHoursStart: int64(arg.QuietHoursStart),
+		Timezone:        arg.Timezone,
+		UpdatedAt:       time.Now().UTC(),
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	slog.Info("User settings updated", "user", arg.UserID)
+	return nil
+}
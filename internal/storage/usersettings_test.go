@@ -0,0 +1,183 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/storage"
+)
+
+func TestUserSettings(t *testing.T) {
+	st := NewTestStorage(t)
+	t.Run("returns defaults when user has no settings", func(t *testing.T) {
+		got, err := st.GetUserSettings("user-1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, storage.DefaultUserSettings("user-1"), got)
+			assert.Equal(t, time.UTC, got.Location())
+			assert.False(t, got.HasQuietHours())
+		}
+	})
+	t.Run("can create and update settings", func(t *testing.T) {
+		want := storage.UserSettings{
+			UserID:          "user-2",
+			DefaultReminder: 3 * time.Hour,
+			DigestHour:      8,
+			DigestMode:      storage.DigestWeekly,
+			DigestWeekday:   time.Monday,
+			ListPageSize:    5,
+			Locale:          "de",
+			QuietHoursEnd:   7,
+			QuietHoursStart: 22,
+			Timezone:        "Europe/Berlin",
+		}
+		err := st.UpdateOrCreateUserSettings(want)
+		if assert.NoError(t, err) {
+			got, err := st.GetUserSettings("user-2")
+			if assert.NoError(t, err) {
+				assert.Equal(t, want, got)
+				assert.Equal(t, "Europe/Berlin", got.Location().String())
+				assert.True(t, got.HasQuietHours())
+			}
+		}
+		want.ListPageSize = 3
+		err = st.UpdateOrCreateUserSettings(want)
+		if assert.NoError(t, err) {
+			got, err := st.GetUserSettings("user-2")
+			if assert.NoError(t, err) {
+				assert.Equal(t, want, got)
+			}
+		}
+	})
+	t.Run("can delete settings", func(t *testing.T) {
+		us := storage.DefaultUserSettings("user-3")
+		us.Timezone = "Asia/Tokyo"
+		if err := st.UpdateOrCreateUserSettings(us); err != nil {
+			t.Fatal(err)
+		}
+		err := st.DeleteUserSettings("user-3")
+		if assert.NoError(t, err) {
+			got, err := st.GetUserSettings("user-3")
+			if assert.NoError(t, err) {
+				assert.Equal(t, storage.DefaultUserSettings("user-3"), got)
+			}
+		}
+	})
+	t.Run("should reject invalid settings", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			modify func(us *storage.UserSettings)
+		}{
+			{"missing user", func(us *storage.UserSettings) { us.UserID = "" }},
+			{"unknown timezone", func(us *storage.UserSettings) { us.Timezone = "Mars/Olympus" }},
+			{"page size too small", func(us *storage.UserSettings) { us.ListPageSize = 0 }},
+			{"page size too large", func(us *storage.UserSettings) { us.ListPageSize = 11 }},
+			{"invalid quiet hours", func(us *storage.UserSettings) { us.QuietHoursStart = 24 }},
+			{"negative default reminder", func(us *storage.UserSettings) { us.DefaultReminder = -time.Hour }},
+			{"unknown digest mode", func(us *storage.UserSettings) { us.DigestMode = "monthly" }},
+			{"invalid digest hour", func(us *storage.UserSettings) { us.DigestHour = 24 }},
+			{"invalid digest weekday", func(us *storage.UserSettings) { us.DigestWeekday = 7 }},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				us := storage.DefaultUserSettings("user-4")
+				tc.modify(&us)
+				err := st.UpdateOrCreateUserSettings(us)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestListDigestUserSettings(t *testing.T) {
+	st := NewTestStorage(t)
+	us1 := storage.DefaultUserSettings("user-1")
+	us1.DigestMode = storage.DigestDaily
+	us2 := storage.DefaultUserSettings("user-2")
+	for _, us := range []storage.UserSettings{us1, us2} {
+		if err := st.UpdateOrCreateUserSettings(us); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := st.ListDigestUserSettings()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []storage.UserSettings{us1}, got)
+	}
+}
+
+func TestUserSettingsNextDigest(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 10, 17, 9, 30, 0, 0, berlin) // a Friday
+	cases := []struct {
+		name    string
+		mode    string
+		hour    int
+		weekday time.Weekday
+		want    time.Time
+	}{
+		{"off", storage.DigestOff, 8, 0, time.Time{}},
+		{"daily later today", storage.DigestDaily, 18, 0, time.Date(2025, 10, 17, 18, 0, 0, 0, berlin)},
+		{"daily tomorrow", storage.DigestDaily, 8, 0, time.Date(2025, 10, 18, 8, 0, 0, 0, berlin)},
+		{"daily at same hour", storage.DigestDaily, 9, 0, time.Date(2025, 10, 18, 9, 0, 0, 0, berlin)},
+		{"weekly next monday", storage.DigestWeekly, 8, time.Monday, time.Date(2025, 10, 20, 8, 0, 0, 0, berlin)},
+		{"weekly later today", storage.DigestWeekly, 18, time.Friday, time.Date(2025, 10, 17, 18, 0, 0, 0, berlin)},
+		{"weekly next week", storage.DigestWeekly, 8, time.Friday, time.Date(2025, 10, 24, 8, 0, 0, 0, berlin)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			us := storage.DefaultUserSettings("user")
+			us.Timezone = "Europe/Berlin"
+			us.DigestMode = tc.mode
+			us.DigestHour = tc.hour
+			us.DigestWeekday = tc.weekday
+			got := us.NextDigest(now.UTC())
+			assert.True(t, tc.want.Equal(got), "want %v, got %v", tc.want, got)
+		})
+	}
+	t.Run("keeps local hour across DST change", func(t *testing.T) {
+		us := storage.DefaultUserSettings("user")
+		us.Timezone = "Europe/Berlin"
+		us.DigestMode = storage.DigestDaily
+		us.DigestHour = 8
+		got := us.NextDigest(time.Date(2025, 10, 25, 9, 0, 0, 0, berlin))
+		assert.Equal(t, time.Date(2025, 10, 26, 7, 0, 0, 0, time.UTC), got.UTC())
+	})
+}
+
+func TestUserSettingsDeliveryTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 10, day, hour, minute, 0, 0, berlin)
+	}
+	cases := []struct {
+		name       string
+		start, end int
+		dueAt      time.Time
+		want       time.Time
+	}{
+		{"no quiet hours", 0, 0, at(17, 3, 0), at(17, 3, 0)},
+		{"before overnight window", 22, 8, at(17, 21, 59), at(17, 21, 59)},
+		{"start of overnight window", 22, 8, at(17, 22, 0), at(18, 8, 0)},
+		{"after midnight in overnight window", 22, 8, at(18, 3, 0), at(18, 8, 0)},
+		{"end of overnight window", 22, 8, at(18, 8, 0), at(18, 8, 0)},
+		{"within window on same day", 0, 8, at(17, 3, 30), at(17, 8, 0)},
+		{"after window on same day", 0, 8, at(17, 9, 0), at(17, 9, 0)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			us := storage.DefaultUserSettings("user")
+			us.Timezone = "Europe/Berlin"
+			us.QuietHoursStart = tc.start
+			us.QuietHoursEnd = tc.end
+			got := us.DeliveryTime(tc.dueAt.UTC())
+			assert.True(t, tc.want.Equal(got), "want %v, got %v", tc.want, got)
+		})
+	}
+}
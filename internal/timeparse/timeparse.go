@@ -0,0 +1,304 @@
+// Package timeparse parses free-form reminder times like "in 45 minutes" or "tomorrow 9am".
+package timeparse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is returned when an input can not be parsed.
+var ErrInvalid = errors.New("invalid time")
+
+// Hour used for days given without a time, e.g. "tomorrow".
+const defaultHour = 9
+
+var (
+	reDuration   = regexp.MustCompile(`^(\d+|a|an)\s*([a-z]+)$`)
+	reAmPm       = regexp.MustCompile(`(\d)\s+(am|pm)\b`)
+	reClock      = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	reWhitespace = regexp.MustCompile(`\s+`)
+)
+
+var absoluteLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"sun":       time.Sunday,
+	"monday":    time.Monday,
+	"mon":       time.Monday,
+	"tuesday":   time.Tuesday,
+	"tue":       time.Tuesday,
+	"wednesday": time.Wednesday,
+	"wed":       time.Wednesday,
+	"thursday":  time.Thursday,
+	"thu":       time.Thursday,
+	"friday":    time.Friday,
+	"fri":       time.Friday,
+	"saturday":  time.Saturday,
+	"sat":       time.Saturday,
+}
+
+// Parse parses a free-form time expression relative to now
+// and returns the resulting time in the location of now.
+//
+// Supported are:
+//   - relative durations: "in 45 minutes", "in 1 hour 30 min", "2h", "in a week"
+//   - days with optional time: "today 17:30", "tomorrow 9am", "tonight"
+//   - weekdays with optional time: "friday", "next friday 17:30", "on mon at 8pm"
+//   - times of today or tomorrow, whichever comes first: "17:30", "9:15pm", "noon"
+//   - ISO timestamps: "2025-10-17", "2025-10-17 17:30", "2025-10-17T17:30:00+02:00"
+//
+// Days given without a time resolve to 09:00.
+func Parse(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = reWhitespace.ReplaceAllString(s, " ")
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty input: %w", ErrInvalid)
+	}
+	loc := now.Location()
+	for _, layout := range absoluteLayouts {
+		t, err := time.ParseInLocation(layout, strings.ToUpper(s), loc)
+		if err == nil {
+			return t.In(loc), nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return time.Date(t.Year(), t.Month(), t.Day(), defaultHour, 0, 0, 0, loc), nil
+	}
+	if x, found := strings.CutPrefix(s, "in "); found {
+		d, err := parseDuration(x)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return d.addTo(now), nil
+	}
+	if d, err := parseDuration(s); err == nil {
+		return d.addTo(now), nil
+	}
+	return parseDayAndTime(s, now)
+}
+
+// relativeDuration represents a duration with calendar components.
+type relativeDuration struct {
+	months   int
+	days     int
+	duration time.Duration
+}
+
+func (d relativeDuration) addTo(t time.Time) time.Time {
+	return t.AddDate(0, d.months, d.days).Add(d.duration)
+}
+
+// parseDuration parses a sequence of amounts and units, e.g. "1 hour and 30 min" or "2h30m".
+func parseDuration(s string) (relativeDuration, error) {
+	var r relativeDuration
+	s = strings.NewReplacer(",", " ", " and ", " ").Replace(s)
+	parts := splitAmounts(s)
+	if len(parts) == 0 {
+		return r, fmt.Errorf("no duration: %s: %w", s, ErrInvalid)
+	}
+	for _, p := range parts {
+		m := reDuration.FindStringSubmatch(p)
+		if m == nil {
+			return r, fmt.Errorf("unknown duration: %s: %w", p, ErrInvalid)
+		}
+		var n int
+		switch m[1] {
+		case "a", "an":
+			n = 1
+		default:
+			var err error
+			n, err = strconv.Atoi(m[1])
+			if err != nil {
+				return r, fmt.Errorf("%s: %w", m[1], ErrInvalid)
+			}
+		}
+		switch m[2] {
+		case "s", "sec", "secs", "second", "seconds":
+			r.duration += time.Duration(n) * time.Second
+		case "m", "min", "mins", "minute", "minutes":
+			r.duration += time.Duration(n) * time.Minute
+		case "h", "hr", "hrs", "hour", "hours":
+			r.duration += time.Duration(n) * time.Hour
+		case "d", "day", "days":
+			r.days += n
+		case "w", "wk", "wks", "week", "weeks":
+			r.days += 7 * n
+		case "mo", "month", "months":
+			r.months += n
+		default:
+			return r, fmt.Errorf("unknown unit: %s: %w", m[2], ErrInvalid)
+		}
+	}
+	return r, nil
+}
+
+// splitAmounts splits s into parts each consisting of an amount and a unit,
+// e.g. "1 hour 30m" into "1 hour" and "30m" and "2h30m" into "2h" and "30m".
+func splitAmounts(s string) []string {
+	var parts []string
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		// an amount can be followed by its unit as separate field
+		if (f == "a" || f == "an" || isDigits(f)) && i < len(fields)-1 {
+			parts = append(parts, f+" "+fields[i+1])
+			i++
+			continue
+		}
+		start := 0
+		for j := 1; j < len(f); j++ {
+			if isDigit(f[j]) && !isDigit(f[j-1]) {
+				parts = append(parts, f[start:j])
+				start = j
+			}
+		}
+		parts = append(parts, f[start:])
+	}
+	return parts
+}
+
+// parseDayAndTime parses expressions consisting of an optional day and an optional time.
+func parseDayAndTime(s string, now time.Time) (time.Time, error) {
+	s = reAmPm.ReplaceAllString(s, "$1$2")
+	fields := strings.Fields(s)
+	var (
+		date    time.Time
+		hasDate bool
+		hasTime bool
+		hour    = defaultHour
+		minute  int
+		weekday = -1
+		isNext  bool
+		isAt    bool
+	)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, f := range fields {
+		switch f {
+		case "today":
+			date, hasDate = today, true
+			continue
+		case "tonight":
+			date, hasDate = today, true
+			if !hasTime {
+				hour = 20
+			}
+			continue
+		case "tomorrow":
+			date, hasDate = today.AddDate(0, 0, 1), true
+			continue
+		case "next":
+			isNext = true
+			continue
+		case "on", "this":
+			continue
+		case "at":
+			isAt = true
+			continue
+		case "noon":
+			hour, minute, hasTime = 12, 0, true
+			continue
+		case "midnight":
+			hour, minute, hasTime = 0, 0, true
+			continue
+		}
+		if wd, ok := weekdays[f]; ok {
+			weekday = int(wd)
+			hasDate = true
+			continue
+		}
+		h, m, ok := parseClock(f, isAt)
+		if !ok {
+			return time.Time{}, fmt.Errorf("unknown word: %s: %w", f, ErrInvalid)
+		}
+		hour, minute, hasTime = h, m, true
+	}
+	if !hasDate && !hasTime {
+		return time.Time{}, fmt.Errorf("no day or time: %s: %w", s, ErrInvalid)
+	}
+	if isNext && weekday < 0 {
+		return time.Time{}, fmt.Errorf("next without weekday: %s: %w", s, ErrInvalid)
+	}
+	at := func(d time.Time) time.Time {
+		return time.Date(d.Year(), d.Month(), d.Day(), hour, minute, 0, 0, now.Location())
+	}
+	switch {
+	case weekday >= 0:
+		days := (weekday - int(today.Weekday()) + 7) % 7
+		if isNext {
+			if days == 0 {
+				days = 7
+			}
+		} else if days == 0 && !at(today).After(now) {
+			days = 7
+		}
+		return at(today.AddDate(0, 0, days)), nil
+	case hasDate:
+		return at(date), nil
+	}
+	t := at(today)
+	if !t.After(now) {
+		t = at(today.AddDate(0, 0, 1))
+	}
+	return t, nil
+}
+
+// parseClock parses times like "17:30", "9am" or "9:15pm".
+// Bare hours like "9" are only accepted when isAt is true.
+func parseClock(s string, isAt bool) (hour, minute int, ok bool) {
+	m := reClock.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, false
+	}
+	if m[2] == "" && m[3] == "" && !isAt {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if minute > 59 {
+		return 0, 0, false
+	}
+	switch m[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour = hour % 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	default:
+		if hour > 23 {
+			return 0, 0, false
+		}
+	}
+	return hour, minute, true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := range len(s) {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
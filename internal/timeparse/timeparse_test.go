@@ -0,0 +1,94 @@
+package timeparse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"example/discord-bookmarker/internal/timeparse"
+)
+
+func TestParse(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Friday, 17. Oct 2025 14:30 local time
+	now := time.Date(2025, 10, 17, 14, 30, 0, 0, loc)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, loc)
+	}
+	cases := []struct {
+		input string
+		want  time.Time
+	}{
+		{"in 45 minutes", now.Add(45 * time.Minute)},
+		{"in 10 seconds", now.Add(10 * time.Second)},
+		{"in 1 hour 30 min", now.Add(90 * time.Minute)},
+		{"in 1 hour and 30 minutes", now.Add(90 * time.Minute)},
+		{"in an hour", now.Add(time.Hour)},
+		{"in a week", at(10, 24, 14, 30)},
+		{"in 2 days", at(10, 19, 14, 30)},
+		{"in 1 month", at(11, 17, 14, 30)},
+		{"2h30m", now.Add(150 * time.Minute)},
+		{"45m", now.Add(45 * time.Minute)},
+		{"  IN 45   Minutes ", now.Add(45 * time.Minute)},
+		{"today 17:30", at(10, 17, 17, 30)},
+		{"tonight", at(10, 17, 20, 0)},
+		{"tomorrow", at(10, 18, 9, 0)},
+		{"tomorrow 9am", at(10, 18, 9, 0)},
+		{"tomorrow at 9 am", at(10, 18, 9, 0)},
+		{"tomorrow at 9", at(10, 18, 9, 0)},
+		{"tomorrow 12am", at(10, 18, 0, 0)},
+		{"tomorrow 12pm", at(10, 18, 12, 0)},
+		{"tomorrow noon", at(10, 18, 12, 0)},
+		{"17:30", at(10, 17, 17, 30)},
+		{"9:15pm", at(10, 17, 21, 15)},
+		{"8am", at(10, 18, 8, 0)},
+		{"midnight", at(10, 18, 0, 0)},
+		{"monday", at(10, 20, 9, 0)},
+		{"on mon at 8pm", at(10, 20, 20, 0)},
+		{"friday 17:30", at(10, 17, 17, 30)},
+		{"friday 10:00", at(10, 24, 10, 0)},
+		{"next friday 17:30", at(10, 24, 17, 30)},
+		{"next saturday", at(10, 18, 9, 0)},
+		{"2025-12-24", at(12, 24, 9, 0)},
+		{"2025-12-24 18:00", at(12, 24, 18, 0)},
+		{"2025-12-24T18:00", at(12, 24, 18, 0)},
+		{"2025-12-24T18:00:00Z", time.Date(2025, 12, 24, 19, 0, 0, 0, loc)},
+		{"2025-10-26 09:00", at(10, 26, 9, 0)}, // after DST change
+		{"in 10 days", at(10, 27, 14, 30)},     // keeps wall clock over DST change
+	}
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := timeparse.Parse(tc.input, now)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, got)
+				assert.Equal(t, loc, got.Location())
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	now := time.Date(2025, 10, 17, 14, 30, 0, 0, time.UTC)
+	for _, input := range []string{
+		"",
+		"soon",
+		"in",
+		"in 5 parsecs",
+		"next",
+		"next week",
+		"tomorrow 25:00",
+		"tomorrow 13pm",
+		"tomorrow 9",
+		"17:75",
+		"2025-13-01",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := timeparse.Parse(input, now)
+			assert.ErrorIs(t, err, timeparse.ErrInvalid)
+		})
+	}
+}